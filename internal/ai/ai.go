@@ -3,10 +3,10 @@ package ai
 import (
 	"context"
 	"fmt"
+	"os"
+	"os/signal"
 	"strings"
 
-	openai "github.com/sashabaranov/go-openai"
-
 	"github.com/doganarif/giq/internal/config"
 )
 
@@ -26,150 +26,151 @@ func GenerateCommitMessage(cfg *config.Config, prompt string) (string, error) {
 // GenerateCommitMessages selects the appropriate provider based on configuration
 // and returns a slice of generated commit message suggestions.
 func GenerateCommitMessages(cfg *config.Config, prompt string) ([]string, error) {
-	if strings.ToLower(cfg.AIProvider) == "azure_openai" {
-		return generateCommitMessagesAzure(cfg, prompt)
-	}
-	return generateCommitMessagesOpenAI(cfg, prompt)
-}
-
-// generateCommitMessagesOpenAI uses the official OpenAI SDK to generate commit messages
-// via the Chat Completion API.
-func generateCommitMessagesOpenAI(cfg *config.Config, prompt string) ([]string, error) {
-	if cfg.AIKey == "" {
-		return nil, fmt.Errorf("OpenAI API key is not configured")
+	provider, err := NewProvider(cfg)
+	if err != nil {
+		return nil, err
 	}
 
-	client := openai.NewClient(cfg.AIKey)
-	req := openai.ChatCompletionRequest{
-		Model:       openai.GPT3Dot5Turbo, // Change as desired.
-		Messages:    []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: prompt}},
-		Temperature: 0.5,
-		MaxTokens:   64,
-		N:           3, // Request three completions.
-	}
+	ctx, cancel := interruptContext()
+	defer cancel()
 
-	resp, err := client.CreateChatCompletion(context.Background(), req)
-	if err != nil {
-		return nil, fmt.Errorf("OpenAI API error: %w", err)
-	}
+	return provider.Complete(ctx, commitRequest(cfg, CommitConversation(prompt, nil, "")))
+}
 
-	if len(resp.Choices) == 0 {
-		return nil, fmt.Errorf("no completions returned from OpenAI")
-	}
+// StreamCommitMessages is like GenerateCommitMessages but reports the
+// suggestions through onEvent while they are being generated. onEvent may be
+// called concurrently. The returned slice holds the final, trimmed suggestions.
+func StreamCommitMessages(ctx context.Context, cfg *config.Config, prompt string, onEvent func(StreamEvent)) ([]string, error) {
+	return StreamCommitConversation(ctx, cfg, CommitConversation(prompt, nil, ""), onEvent)
+}
 
-	suggestions := make([]string, 0, len(resp.Choices))
-	for _, choice := range resp.Choices {
-		suggestions = append(suggestions, strings.TrimSpace(choice.Message.Content))
+// StreamCommitConversation is like StreamCommitMessages for a conversation
+// built by CommitConversation.
+func StreamCommitConversation(ctx context.Context, cfg *config.Config, messages []Message, onEvent func(StreamEvent)) ([]string, error) {
+	provider, err := NewProvider(cfg)
+	if err != nil {
+		return nil, err
 	}
+	return stream(ctx, provider, commitRequest(cfg, messages), onEvent)
+}
 
-	return suggestions, nil
+// CommitConversation returns the messages asking for commit messages for
+// prompt. If instruction is not empty, the previous suggestions are passed
+// back as the model's earlier answer and revised according to instruction.
+func CommitConversation(prompt string, previous []string, instruction string) []Message {
+	messages := []Message{{Role: RoleUser, Content: prompt}}
+	if instruction == "" {
+		return messages
+	}
+
+	var answer strings.Builder
+	answer.WriteString("Suggested commit messages:\n")
+	for i, s := range previous {
+		fmt.Fprintf(&answer, "\n%d. %s\n", i+1, s)
+	}
+	return append(messages,
+		Message{Role: RoleAssistant, Content: answer.String()},
+		Message{Role: RoleUser, Content: fmt.Sprintf(
+			"Revise the commit message according to this instruction: %s\n"+
+				"Answer with a single commit message in the format requested before, without numbering or commentary.",
+			instruction,
+		)},
+	)
 }
 
-// generateCommitMessagesAzure uses the official OpenAI SDK configured for Azure OpenAI.
-func generateCommitMessagesAzure(cfg *config.Config, prompt string) ([]string, error) {
-	// Ensure that all required Azure configuration values are provided.
-	if cfg.AzureAPIKey == "" || cfg.AzureEndpoint == "" || cfg.AzureDeploymentID == "" || cfg.AzureAPIVersion == "" {
-		return nil, fmt.Errorf("Azure OpenAI configuration is incomplete")
+// commitRequest builds the request used for commit message suggestions.
+func commitRequest(cfg *config.Config, messages []Message) Request {
+	// A subject line fits in 64 tokens; a body needs room for a paragraph or two.
+	limit := 64
+	if cfg.CommitBody {
+		limit = 320
 	}
-
-	azureConfig := openai.DefaultAzureConfig(cfg.AzureAPIKey, cfg.AzureEndpoint)
-	azureConfig.AzureModelMapperFunc = func(model string) string {
-		azureModelMapping := map[string]string{
-			// Map the desired model to your Azure deployment name.
-			openai.GPT4o: cfg.AzureDeploymentID,
-		}
-		return azureModelMapping[model]
+	return Request{
+		Messages:    messages,
+		Temperature: cfg.Temperature,
+		MaxTokens:   maxTokens(cfg, limit),
+		N:           candidates(cfg),
 	}
+}
 
-	client := openai.NewClientWithConfig(azureConfig)
-	req := openai.ChatCompletionRequest{
-		Model:       openai.GPT4o, // Change as desired.
-		Messages:    []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: prompt}},
-		Temperature: 0.5,
-		MaxTokens:   64,
-		N:           3, // Request three completions.
-	}
+// GenerateStatusInsights generates AI-based insights based on the diff output
+// of the staged changes. It asks the AI to provide a concise summary describing
+// what changed in each file.
+func GenerateStatusInsights(cfg *config.Config, diff string) (string, error) {
+	prompt := StatusInsightsPrompt(diff)
 
-	resp, err := client.CreateChatCompletion(context.Background(), req)
+	provider, err := NewProvider(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("Azure OpenAI API error: %w", err)
+		return "", err
 	}
 
-	if len(resp.Choices) == 0 {
-		return nil, fmt.Errorf("no completions returned from Azure OpenAI")
-	}
+	ctx, cancel := interruptContext()
+	defer cancel()
 
-	suggestions := make([]string, 0, len(resp.Choices))
-	for _, choice := range resp.Choices {
-		suggestions = append(suggestions, strings.TrimSpace(choice.Message.Content))
+	choices, err := provider.Complete(ctx, Request{
+		Messages:    []Message{{Role: RoleUser, Content: prompt}},
+		Temperature: cfg.Temperature,
+		MaxTokens:   maxTokens(cfg, 128),
+	})
+	if err != nil {
+		return "", err
 	}
-
-	return suggestions, nil
+	return choices[0], nil
 }
 
-// GenerateStatusInsights generates AI-based insights based on the diff output
-// of the staged changes. It asks the AI to provide a concise summary describing
-// what changed in each file.
-func GenerateStatusInsights(cfg *config.Config, diff string) (string, error) {
-	// Build a prompt that asks the AI to describe the changes (per file) based on the diff.
-	prompt := fmt.Sprintf(
+// StatusInsightsPrompt builds the prompt GenerateStatusInsights sends for diff.
+func StatusInsightsPrompt(diff string) string {
+	// Ask the AI to describe the changes (per file) based on the diff.
+	return fmt.Sprintf(
 		"Based on the following git diff output for staged changes, provide a single concise sentence that summarizes the changes made to each file. "+
 			"Indicate for each file whether code was added, removed, or modified, and if possible, what kind of changes occurred (for example, bug fixes, refactoring, or feature additions). "+
 			"Do not simply list the file names. Diff:\n\n%s",
 		diff,
 	)
-
-	if strings.ToLower(cfg.AIProvider) == "azure_openai" {
-		return generateStatusInsightsAzure(cfg, prompt)
-	}
-	return generateStatusInsightsOpenAI(cfg, prompt)
 }
 
-func generateStatusInsightsOpenAI(cfg *config.Config, prompt string) (string, error) {
-	if cfg.AIKey == "" {
-		return "", fmt.Errorf("OpenAI API key is not configured")
-	}
-	client := openai.NewClient(cfg.AIKey)
-	req := openai.ChatCompletionRequest{
-		Model:       openai.GPT3Dot5Turbo,
-		Messages:    []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: prompt}},
-		Temperature: 0.5,
-		MaxTokens:   128,
-	}
-	resp, err := client.CreateChatCompletion(context.Background(), req)
+// GenerateText sends prompt as a single request and returns the answer.
+// limit is the completion token limit. max_tokens does not apply, since the
+// caller sizes limit to the longer answers it expects, such as a commit plan
+// or a pull request description.
+func GenerateText(cfg *config.Config, prompt string, limit int) (string, error) {
+	provider, err := NewProvider(cfg)
 	if err != nil {
-		return "", fmt.Errorf("OpenAI API error: %w", err)
+		return "", err
 	}
-	if len(resp.Choices) == 0 {
-		return "", fmt.Errorf("no explanation returned from OpenAI")
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	choices, err := provider.Complete(ctx, Request{
+		Messages:    []Message{{Role: RoleUser, Content: prompt}},
+		Temperature: cfg.Temperature,
+		MaxTokens:   limit,
+	})
+	if err != nil {
+		return "", err
 	}
-	return strings.TrimSpace(resp.Choices[0].Message.Content), nil
+	return choices[0], nil
 }
 
-func generateStatusInsightsAzure(cfg *config.Config, prompt string) (string, error) {
-	if cfg.AzureAPIKey == "" || cfg.AzureEndpoint == "" || cfg.AzureDeploymentID == "" || cfg.AzureAPIVersion == "" {
-		return "", fmt.Errorf("Azure OpenAI configuration is incomplete")
-	}
-	azureConfig := openai.DefaultAzureConfig(cfg.AzureAPIKey, cfg.AzureEndpoint)
-	azureConfig.AzureModelMapperFunc = func(model string) string {
-		azureModelMapping := map[string]string{
-			openai.GPT4o: cfg.AzureDeploymentID,
-		}
-		return azureModelMapping[model]
-	}
-	client := openai.NewClientWithConfig(azureConfig)
-	req := openai.ChatCompletionRequest{
-		Model:       openai.GPT4o,
-		Messages:    []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: prompt}},
-		Temperature: 0.5,
-		MaxTokens:   128,
-	}
-	resp, err := client.CreateChatCompletion(context.Background(), req)
-	if err != nil {
-		return "", fmt.Errorf("Azure OpenAI API error: %w", err)
+// interruptContext returns a context that is cancelled when the user presses
+// Ctrl+C, so pending requests and retry delays are abandoned promptly.
+func interruptContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt)
+}
+
+// maxTokens returns the configured completion token limit, or def if none is set.
+func maxTokens(cfg *config.Config, def int) int {
+	if cfg.MaxTokens > 0 {
+		return cfg.MaxTokens
 	}
-	if len(resp.Choices) == 0 {
-		return "", fmt.Errorf("no explanation returned from Azure OpenAI")
+	return def
+}
+
+// candidates returns the configured number of commit message suggestions.
+func candidates(cfg *config.Config) int {
+	if cfg.Candidates > 0 {
+		return cfg.Candidates
 	}
-	return strings.TrimSpace(resp.Choices[0].Message.Content), nil
+	return 3
 }
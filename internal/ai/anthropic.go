@@ -0,0 +1,173 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/doganarif/giq/internal/config"
+)
+
+func init() {
+	Register("anthropic", newAnthropicProvider)
+}
+
+const (
+	defaultAnthropicBaseURL = "https://api.anthropic.com"
+	defaultAnthropicModel   = "claude-haiku-4-5"
+	anthropicAPIVersion     = "2023-06-01"
+)
+
+// anthropicProvider talks to the Anthropic Messages API.
+type anthropicProvider struct {
+	client  *http.Client
+	baseURL string
+	apiKey  string
+	model   string
+}
+
+func newAnthropicProvider(cfg *config.Config) (Provider, error) {
+	if cfg.AnthropicAPIKey == "" {
+		return nil, fmt.Errorf("Anthropic API key is not configured")
+	}
+
+	p := &anthropicProvider{
+		client:  newHTTPClient(cfg),
+		baseURL: defaultAnthropicBaseURL,
+		apiKey:  cfg.AnthropicAPIKey,
+		model:   defaultAnthropicModel,
+	}
+	if cfg.AnthropicBaseURL != "" {
+		p.baseURL = strings.TrimRight(cfg.AnthropicBaseURL, "/")
+	}
+	if cfg.AnthropicModel != "" {
+		p.model = cfg.AnthropicModel
+	}
+	return p, nil
+}
+
+func (p *anthropicProvider) Name() string { return "anthropic" }
+
+func (p *anthropicProvider) Model() string { return p.model }
+
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type anthropicRequest struct {
+	Model       string             `json:"model"`
+	System      string             `json:"system,omitempty"`
+	Messages    []anthropicMessage `json:"messages"`
+	MaxTokens   int                `json:"max_tokens"`
+	Temperature float32            `json:"temperature"`
+	Stream      bool               `json:"stream,omitempty"`
+}
+
+type anthropicResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+}
+
+// anthropicEvent is a server-sent event of a streamed response.
+type anthropicEvent struct {
+	Type  string `json:"type"`
+	Delta struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"delta"`
+	Error struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Complete sends the request to the Messages API. The API has no equivalent
+// of the OpenAI "n" parameter, so N candidates are produced by N parallel calls.
+func (p *anthropicProvider) Complete(ctx context.Context, req Request) ([]string, error) {
+	body := p.newRequest(req)
+	choices, err := completeN(ctx, req.N, func(ctx context.Context) (string, error) {
+		var resp anthropicResponse
+		if err := postJSON(ctx, p.client, p.baseURL+"/v1/messages", p.headers(), body, &resp); err != nil {
+			return "", err
+		}
+		var text strings.Builder
+		for _, block := range resp.Content {
+			if block.Type == "text" {
+				text.WriteString(block.Text)
+			}
+		}
+		return text.String(), nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Anthropic API error: %w", err)
+	}
+	if len(choices) == 0 {
+		return nil, fmt.Errorf("no completions returned from Anthropic")
+	}
+	return choices, nil
+}
+
+// Stream is like Complete but streams every parallel call.
+func (p *anthropicProvider) Stream(ctx context.Context, req Request, onEvent func(StreamEvent)) ([]string, error) {
+	body := p.newRequest(req)
+	body.Stream = true
+	choices, err := streamN(ctx, req.N, onEvent, func(ctx context.Context, emit func(string)) (string, error) {
+		var text strings.Builder
+		err := postStream(ctx, p.client, p.baseURL+"/v1/messages", p.headers(), body, func(line []byte) error {
+			data, ok := sseData(line)
+			if !ok {
+				return nil
+			}
+			var ev anthropicEvent
+			if err := json.Unmarshal(data, &ev); err != nil {
+				return fmt.Errorf("decoding event: %w", err)
+			}
+			switch ev.Type {
+			case "content_block_delta":
+				if ev.Delta.Type == "text_delta" {
+					text.WriteString(ev.Delta.Text)
+					emit(ev.Delta.Text)
+				}
+			case "error":
+				return fmt.Errorf("%s", ev.Error.Message)
+			}
+			return nil
+		})
+		return text.String(), err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Anthropic API error: %w", err)
+	}
+	if len(choices) == 0 {
+		return nil, fmt.Errorf("no completions returned from Anthropic")
+	}
+	return choices, nil
+}
+
+func (p *anthropicProvider) newRequest(req Request) anthropicRequest {
+	body := anthropicRequest{
+		Model:       p.model,
+		MaxTokens:   req.MaxTokens,
+		Temperature: req.Temperature,
+	}
+	for _, m := range req.Messages {
+		// System prompts are a top-level field rather than a message role.
+		if m.Role == RoleSystem {
+			body.System = strings.TrimSpace(body.System + "\n\n" + m.Content)
+			continue
+		}
+		body.Messages = append(body.Messages, anthropicMessage{Role: m.Role, Content: m.Content})
+	}
+	return body
+}
+
+func (p *anthropicProvider) headers() map[string]string {
+	return map[string]string{
+		"x-api-key":         p.apiKey,
+		"anthropic-version": anthropicAPIVersion,
+	}
+}
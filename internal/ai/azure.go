@@ -0,0 +1,55 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+
+	openai "github.com/sashabaranov/go-openai"
+
+	"github.com/doganarif/giq/internal/config"
+)
+
+func init() {
+	Register("azure_openai", newAzureProvider)
+}
+
+// azureProvider talks to an Azure OpenAI deployment through the go-openai SDK.
+type azureProvider struct {
+	client *openai.Client
+	model  string
+}
+
+func newAzureProvider(cfg *config.Config) (Provider, error) {
+	// Ensure that all required Azure configuration values are provided.
+	if cfg.AzureAPIKey == "" || cfg.AzureEndpoint == "" || cfg.AzureDeploymentID == "" || cfg.AzureAPIVersion == "" {
+		return nil, fmt.Errorf("Azure OpenAI configuration is incomplete")
+	}
+
+	azureConfig := openai.DefaultAzureConfig(cfg.AzureAPIKey, cfg.AzureEndpoint)
+	azureConfig.HTTPClient = newHTTPClient(cfg)
+	azureConfig.AzureModelMapperFunc = func(model string) string {
+		// Every request is routed to the configured deployment.
+		return cfg.AzureDeploymentID
+	}
+
+	p := &azureProvider{
+		client: openai.NewClientWithConfig(azureConfig),
+		model:  openai.GPT4o,
+	}
+	if cfg.Model != "" {
+		p.model = cfg.Model
+	}
+	return p, nil
+}
+
+func (p *azureProvider) Name() string { return "azure_openai" }
+
+func (p *azureProvider) Model() string { return p.model }
+
+func (p *azureProvider) Complete(ctx context.Context, req Request) ([]string, error) {
+	return chatCompletion(ctx, p.client, p.model, req, "Azure OpenAI")
+}
+
+func (p *azureProvider) Stream(ctx context.Context, req Request, onEvent func(StreamEvent)) ([]string, error) {
+	return chatCompletionStream(ctx, p.client, p.model, req, "Azure OpenAI", onEvent)
+}
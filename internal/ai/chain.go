@@ -0,0 +1,118 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/doganarif/giq/internal/config"
+)
+
+// defaultTimeout bounds every provider call that has no configured timeout.
+const defaultTimeout = 60 * time.Second
+
+// chainMember is a provider together with the deadline applied to its calls.
+type chainMember struct {
+	provider Provider
+	timeout  time.Duration
+}
+
+// chainProvider tries its members in order and returns the first successful
+// answer. A member that errors, times out or returns no choices is skipped.
+type chainProvider struct {
+	members []chainMember
+	verbose bool
+}
+
+func (c *chainProvider) Name() string {
+	names := make([]string, 0, len(c.members))
+	for _, m := range c.members {
+		names = append(names, m.provider.Name())
+	}
+	return strings.Join(names, ",")
+}
+
+// Model returns the model of the first member, which is tried first.
+func (c *chainProvider) Model() string {
+	return c.members[0].provider.Model()
+}
+
+func (c *chainProvider) Complete(ctx context.Context, req Request) ([]string, error) {
+	return c.run(ctx, func(ctx context.Context, p Provider) ([]string, bool, error) {
+		choices, err := p.Complete(ctx, req)
+		return choices, false, err
+	})
+}
+
+// Stream behaves like Complete but streams through members that support it.
+// Once a member has produced output the chain can no longer fall back, since
+// the caller has already seen partial text, so its error is returned as is.
+func (c *chainProvider) Stream(ctx context.Context, req Request, onEvent func(StreamEvent)) ([]string, error) {
+	return c.run(ctx, func(ctx context.Context, p Provider) ([]string, bool, error) {
+		var emitted atomic.Bool
+		choices, err := stream(ctx, p, req, func(ev StreamEvent) {
+			emitted.Store(true)
+			onEvent(ev)
+		})
+		return choices, emitted.Load(), err
+	})
+}
+
+// run calls each member in turn with its timeout applied until one succeeds.
+// call reports whether the member already produced output, which rules out
+// falling back to the next member.
+func (c *chainProvider) run(ctx context.Context, call func(ctx context.Context, p Provider) ([]string, bool, error)) ([]string, error) {
+	var errs []error
+	for _, m := range c.members {
+		start := time.Now()
+		callCtx, cancel := context.WithTimeout(ctx, m.timeout)
+		choices, emitted, err := call(callCtx, m.provider)
+		// An answer that arrived just before the deadline still counts.
+		if err != nil && errors.Is(callCtx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("%s timed out after %s", m.provider.Name(), m.timeout)
+		}
+		cancel()
+		if err == nil && len(choices) == 0 {
+			err = fmt.Errorf("no completions returned from %s", m.provider.Name())
+		}
+		if err == nil {
+			c.logf("AI provider %s answered in %s", m.provider.Name(), time.Since(start).Round(time.Millisecond))
+			return choices, nil
+		}
+
+		c.logf("AI provider %s failed: %v", m.provider.Name(), err)
+		errs = append(errs, err)
+		// Stop immediately if the caller gave up, e.g. on Ctrl+C.
+		if ctx.Err() != nil || emitted {
+			break
+		}
+	}
+	return nil, errors.Join(errs...)
+}
+
+func (c *chainProvider) logf(format string, args ...any) {
+	logVerbose(c.verbose, format, args...)
+}
+
+// logVerbose prints a diagnostic line to stderr when verbose output is enabled.
+func logVerbose(enabled bool, format string, args ...any) {
+	if enabled {
+		fmt.Fprintf(os.Stderr, "giq: "+format+"\n", args...)
+	}
+}
+
+// providerTimeout returns the deadline configured for the named provider,
+// falling back to the global timeout and then to defaultTimeout.
+func providerTimeout(cfg *config.Config, name string) time.Duration {
+	if t := cfg.ProviderTimeouts[name]; t > 0 {
+		return t
+	}
+	if cfg.Timeout > 0 {
+		return cfg.Timeout
+	}
+	return defaultTimeout
+}
@@ -0,0 +1,179 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/doganarif/giq/internal/config"
+)
+
+func init() {
+	Register("gemini", newGeminiProvider)
+}
+
+const (
+	geminiBaseURL      = "https://generativelanguage.googleapis.com/v1beta"
+	defaultGeminiModel = "gemini-2.0-flash"
+)
+
+// geminiProvider talks to the Google Gemini generateContent API.
+type geminiProvider struct {
+	client *http.Client
+	apiKey string
+	model  string
+}
+
+func newGeminiProvider(cfg *config.Config) (Provider, error) {
+	if cfg.GeminiAPIKey == "" {
+		return nil, fmt.Errorf("Gemini API key is not configured")
+	}
+
+	p := &geminiProvider{
+		client: newHTTPClient(cfg),
+		apiKey: cfg.GeminiAPIKey,
+		model:  defaultGeminiModel,
+	}
+	if cfg.GeminiModel != "" {
+		p.model = cfg.GeminiModel
+	}
+	return p, nil
+}
+
+func (p *geminiProvider) Name() string { return "gemini" }
+
+func (p *geminiProvider) Model() string { return p.model }
+
+type geminiPart struct {
+	Text string `json:"text"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiGenerationConfig struct {
+	Temperature     float32 `json:"temperature"`
+	MaxOutputTokens int     `json:"maxOutputTokens,omitempty"`
+	CandidateCount  int     `json:"candidateCount,omitempty"`
+}
+
+type geminiRequest struct {
+	Contents          []geminiContent        `json:"contents"`
+	SystemInstruction *geminiContent         `json:"systemInstruction,omitempty"`
+	GenerationConfig  geminiGenerationConfig `json:"generationConfig"`
+}
+
+type geminiCandidate struct {
+	Content      geminiContent `json:"content"`
+	Index        int           `json:"index"`
+	FinishReason string        `json:"finishReason"`
+}
+
+type geminiResponse struct {
+	Candidates []geminiCandidate `json:"candidates"`
+}
+
+func (p *geminiProvider) Complete(ctx context.Context, req Request) ([]string, error) {
+	var resp geminiResponse
+	if err := postJSON(ctx, p.client, p.endpoint("generateContent"), p.headers(), p.newRequest(req), &resp); err != nil {
+		return nil, fmt.Errorf("Gemini API error: %w", err)
+	}
+
+	choices := make([]string, 0, len(resp.Candidates))
+	for _, c := range resp.Candidates {
+		choices = append(choices, c.text())
+	}
+	choices = trimChoices(choices)
+	if len(choices) == 0 {
+		return nil, fmt.Errorf("no completions returned from Gemini")
+	}
+	return choices, nil
+}
+
+// Stream uses streamGenerateContent with server-sent events. Each event
+// carries the next fragment of one or more candidates.
+func (p *geminiProvider) Stream(ctx context.Context, req Request, onEvent func(StreamEvent)) ([]string, error) {
+	var texts []string
+	err := postStream(ctx, p.client, p.endpoint("streamGenerateContent")+"?alt=sse", p.headers(), p.newRequest(req), func(line []byte) error {
+		data, ok := sseData(line)
+		if !ok {
+			return nil
+		}
+		var resp geminiResponse
+		if err := json.Unmarshal(data, &resp); err != nil {
+			return fmt.Errorf("decoding event: %w", err)
+		}
+		for _, c := range resp.Candidates {
+			if c.Index < 0 {
+				continue
+			}
+			for len(texts) <= c.Index {
+				texts = append(texts, "")
+			}
+			delta := c.text()
+			texts[c.Index] += delta
+			done := c.FinishReason != ""
+			if delta != "" || done {
+				onEvent(StreamEvent{Index: c.Index, Delta: delta, Done: done})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Gemini API error: %w", err)
+	}
+
+	choices := trimChoices(texts)
+	if len(choices) == 0 {
+		return nil, fmt.Errorf("no completions returned from Gemini")
+	}
+	return choices, nil
+}
+
+func (p *geminiProvider) newRequest(req Request) geminiRequest {
+	body := geminiRequest{
+		GenerationConfig: geminiGenerationConfig{
+			Temperature:     req.Temperature,
+			MaxOutputTokens: req.MaxTokens,
+		},
+	}
+	if req.N > 1 {
+		body.GenerationConfig.CandidateCount = req.N
+	}
+	for _, m := range req.Messages {
+		switch m.Role {
+		case RoleSystem:
+			if body.SystemInstruction == nil {
+				body.SystemInstruction = &geminiContent{}
+			}
+			body.SystemInstruction.Parts = append(body.SystemInstruction.Parts, geminiPart{Text: m.Content})
+		case RoleAssistant:
+			// Gemini calls the assistant role "model".
+			body.Contents = append(body.Contents, geminiContent{Role: "model", Parts: []geminiPart{{Text: m.Content}}})
+		default:
+			body.Contents = append(body.Contents, geminiContent{Role: "user", Parts: []geminiPart{{Text: m.Content}}})
+		}
+	}
+	return body
+}
+
+func (p *geminiProvider) endpoint(method string) string {
+	return fmt.Sprintf("%s/models/%s:%s", geminiBaseURL, url.PathEscape(p.model), method)
+}
+
+func (p *geminiProvider) headers() map[string]string {
+	return map[string]string{"x-goog-api-key": p.apiKey}
+}
+
+func (c geminiCandidate) text() string {
+	var text strings.Builder
+	for _, part := range c.Content.Parts {
+		text.WriteString(part.Text)
+	}
+	return text.String()
+}
@@ -0,0 +1,122 @@
+package ai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// statusError is returned by postJSON when the server answers with a non-2xx status.
+type statusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *statusError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("status %d: %s", e.StatusCode, e.Message)
+}
+
+// postJSON marshals body, POSTs it to url with the given headers and decodes
+// the JSON response into out. Providers without an SDK use it for their calls.
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, body, out any) error {
+	resp, err := post(ctx, client, url, headers, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
+
+// postStream is like postJSON for streaming endpoints. It calls onLine for
+// every non-empty line of the response body, which covers both server-sent
+// events and newline delimited JSON.
+func postStream(ctx context.Context, client *http.Client, url string, headers map[string]string, body any, onLine func(line []byte) error) error {
+	resp, err := post(ctx, client, url, headers, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if err := onLine(line); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// sseData returns the payload of a server-sent "data:" line.
+func sseData(line []byte) ([]byte, bool) {
+	data, ok := bytes.CutPrefix(line, []byte("data:"))
+	return bytes.TrimSpace(data), ok
+}
+
+// post sends body as JSON and returns the response if its status is 2xx.
+func post(ctx context.Context, client *http.Client, url string, headers map[string]string, body any) (*http.Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return nil, &statusError{StatusCode: resp.StatusCode, Message: errorMessage(data)}
+	}
+	return resp, nil
+}
+
+// errorMessage extracts a human readable message from an error response body.
+// Most APIs use either {"error": {"message": "..."}} or {"error": "..."}.
+func errorMessage(data []byte) string {
+	var nested struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(data, &nested) == nil && nested.Error.Message != "" {
+		return nested.Error.Message
+	}
+	var flat struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(data, &flat) == nil && flat.Error != "" {
+		return flat.Error
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,139 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/doganarif/giq/internal/config"
+)
+
+func init() {
+	Register("ollama", newOllamaProvider)
+}
+
+const (
+	defaultOllamaHost  = "http://localhost:11434"
+	defaultOllamaModel = "llama3.2"
+)
+
+// ollamaProvider talks to a local Ollama server (or any server implementing
+// the Ollama /api/chat endpoint) so that diffs never leave the machine.
+type ollamaProvider struct {
+	client    *http.Client
+	host      string
+	model     string
+	keepAlive string
+}
+
+func newOllamaProvider(cfg *config.Config) (Provider, error) {
+	p := &ollamaProvider{
+		client:    newHTTPClient(cfg),
+		host:      defaultOllamaHost,
+		model:     defaultOllamaModel,
+		keepAlive: cfg.OllamaKeepAlive,
+	}
+	if cfg.OllamaHost != "" {
+		p.host = strings.TrimRight(cfg.OllamaHost, "/")
+		if !strings.Contains(p.host, "://") {
+			p.host = "http://" + p.host
+		}
+	}
+	if cfg.OllamaModel != "" {
+		p.model = cfg.OllamaModel
+	}
+	return p, nil
+}
+
+func (p *ollamaProvider) Name() string { return "ollama" }
+
+func (p *ollamaProvider) Model() string { return p.model }
+
+type ollamaMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type ollamaOptions struct {
+	Temperature float32 `json:"temperature"`
+	NumPredict  int     `json:"num_predict,omitempty"`
+}
+
+type ollamaChatRequest struct {
+	Model     string          `json:"model"`
+	Messages  []ollamaMessage `json:"messages"`
+	Stream    bool            `json:"stream"`
+	KeepAlive string          `json:"keep_alive,omitempty"`
+	Options   ollamaOptions   `json:"options"`
+}
+
+type ollamaChatResponse struct {
+	Message ollamaMessage `json:"message"`
+	Done    bool          `json:"done"`
+	Error   string        `json:"error"`
+}
+
+// Complete sends the request to /api/chat. Ollama returns a single completion
+// per call, so N candidates are produced by N parallel calls.
+func (p *ollamaProvider) Complete(ctx context.Context, req Request) ([]string, error) {
+	body := p.newRequest(req)
+	choices, err := completeN(ctx, req.N, func(ctx context.Context) (string, error) {
+		var resp ollamaChatResponse
+		if err := postJSON(ctx, p.client, p.host+"/api/chat", nil, body, &resp); err != nil {
+			return "", err
+		}
+		return resp.Message.Content, nil
+	})
+	return p.result(choices, err)
+}
+
+// Stream is like Complete but streams every parallel call. Ollama streams
+// newline delimited JSON objects until one has "done" set.
+func (p *ollamaProvider) Stream(ctx context.Context, req Request, onEvent func(StreamEvent)) ([]string, error) {
+	body := p.newRequest(req)
+	body.Stream = true
+	choices, err := streamN(ctx, req.N, onEvent, func(ctx context.Context, emit func(string)) (string, error) {
+		var text strings.Builder
+		err := postStream(ctx, p.client, p.host+"/api/chat", nil, body, func(line []byte) error {
+			var chunk ollamaChatResponse
+			if err := json.Unmarshal(line, &chunk); err != nil {
+				return fmt.Errorf("decoding chunk: %w", err)
+			}
+			if chunk.Error != "" {
+				return fmt.Errorf("%s", chunk.Error)
+			}
+			text.WriteString(chunk.Message.Content)
+			emit(chunk.Message.Content)
+			return nil
+		})
+		return text.String(), err
+	})
+	return p.result(choices, err)
+}
+
+func (p *ollamaProvider) newRequest(req Request) ollamaChatRequest {
+	body := ollamaChatRequest{
+		Model:     p.model,
+		KeepAlive: p.keepAlive,
+		Options: ollamaOptions{
+			Temperature: req.Temperature,
+			NumPredict:  req.MaxTokens,
+		},
+	}
+	for _, m := range req.Messages {
+		body.Messages = append(body.Messages, ollamaMessage{Role: m.Role, Content: m.Content})
+	}
+	return body
+}
+
+func (p *ollamaProvider) result(choices []string, err error) ([]string, error) {
+	if err != nil {
+		return nil, fmt.Errorf("Ollama API error (is Ollama running at %s?): %w", p.host, err)
+	}
+	if len(choices) == 0 {
+		return nil, fmt.Errorf("no completions returned from Ollama")
+	}
+	return choices, nil
+}
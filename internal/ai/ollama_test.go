@@ -0,0 +1,153 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/doganarif/giq/internal/config"
+)
+
+// ollamaStub serves /api/chat, answering every request with handle.
+func ollamaStub(t *testing.T, handle func(w http.ResponseWriter, req ollamaChatRequest)) (*ollamaProvider, *[]ollamaChatRequest) {
+	t.Helper()
+	var mu sync.Mutex
+	var requests []ollamaChatRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" || r.Method != http.MethodPost {
+			http.NotFound(w, r)
+			return
+		}
+		var req ollamaChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		requests = append(requests, req)
+		mu.Unlock()
+		handle(w, req)
+	}))
+	t.Cleanup(srv.Close)
+
+	p, err := newOllamaProvider(&config.Config{OllamaHost: srv.URL, OllamaModel: "test-model", OllamaKeepAlive: "1m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p.(*ollamaProvider), &requests
+}
+
+func TestOllamaComplete(t *testing.T) {
+	var mu sync.Mutex
+	n := 0
+	p, requests := ollamaStub(t, func(w http.ResponseWriter, req ollamaChatRequest) {
+		mu.Lock()
+		n++
+		answer := fmt.Sprintf("  Suggestion %d\n", n)
+		mu.Unlock()
+		json.NewEncoder(w).Encode(ollamaChatResponse{Message: ollamaMessage{Role: RoleAssistant, Content: answer}, Done: true})
+	})
+
+	choices, err := p.Complete(context.Background(), Request{
+		Messages:    []Message{{Role: RoleUser, Content: "describe the diff"}},
+		Temperature: 0.2,
+		MaxTokens:   64,
+		N:           2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(choices) != 2 || !strings.HasPrefix(choices[0], "Suggestion ") || !strings.HasPrefix(choices[1], "Suggestion ") {
+		t.Fatalf("choices = %q, want two trimmed suggestions", choices)
+	}
+
+	// Ollama has no n parameter, so every candidate is a call of its own.
+	if len(*requests) != 2 {
+		t.Fatalf("got %d requests, want 2", len(*requests))
+	}
+	req := (*requests)[0]
+	if req.Model != "test-model" || req.KeepAlive != "1m" || req.Stream {
+		t.Errorf("request = %+v, want model test-model, keep_alive 1m, no streaming", req)
+	}
+	if req.Options.NumPredict != 64 || req.Options.Temperature != 0.2 {
+		t.Errorf("options = %+v, want num_predict 64, temperature 0.2", req.Options)
+	}
+	if len(req.Messages) != 1 || req.Messages[0].Role != RoleUser || req.Messages[0].Content != "describe the diff" {
+		t.Errorf("messages = %+v", req.Messages)
+	}
+}
+
+func TestOllamaCompleteError(t *testing.T) {
+	p, _ := ollamaStub(t, func(w http.ResponseWriter, req ollamaChatRequest) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error":"model \"test-model\" not found"}`)
+	})
+
+	_, err := p.Complete(context.Background(), Request{Messages: []Message{{Role: RoleUser, Content: "hi"}}})
+	if err == nil || !strings.Contains(err.Error(), `model "test-model" not found`) {
+		t.Fatalf("err = %v, want the server's error message", err)
+	}
+}
+
+func TestOllamaStream(t *testing.T) {
+	p, requests := ollamaStub(t, func(w http.ResponseWriter, req ollamaChatRequest) {
+		for _, chunk := range []string{
+			`{"message":{"role":"assistant","content":"Add "},"done":false}`,
+			`{"message":{"role":"assistant","content":"stub "},"done":false}`,
+			``,
+			`{"message":{"role":"assistant","content":"tests"},"done":false}`,
+			`{"message":{"role":"assistant","content":""},"done":true}`,
+		} {
+			fmt.Fprintln(w, chunk)
+		}
+	})
+
+	var mu sync.Mutex
+	var deltas []string
+	done := 0
+	choices, err := p.Stream(context.Background(), Request{Messages: []Message{{Role: RoleUser, Content: "hi"}}}, func(ev StreamEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		if ev.Done {
+			done++
+		}
+		if ev.Delta != "" {
+			deltas = append(deltas, ev.Delta)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(choices) != 1 || choices[0] != "Add stub tests" {
+		t.Fatalf("choices = %q, want [\"Add stub tests\"]", choices)
+	}
+	if strings.Join(deltas, "|") != "Add |stub |tests" || done != 1 {
+		t.Errorf("deltas = %q, done events = %d", deltas, done)
+	}
+	if !(*requests)[0].Stream {
+		t.Error("request did not ask for streaming")
+	}
+}
+
+func TestOllamaStreamErrorChunk(t *testing.T) {
+	p, _ := ollamaStub(t, func(w http.ResponseWriter, req ollamaChatRequest) {
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"Partial"},"done":false}`)
+		fmt.Fprintln(w, `{"error":"out of memory"}`)
+	})
+
+	var done bool
+	_, err := p.Stream(context.Background(), Request{Messages: []Message{{Role: RoleUser, Content: "hi"}}}, func(ev StreamEvent) {
+		done = done || ev.Done
+	})
+	if err == nil || !strings.Contains(err.Error(), "out of memory") {
+		t.Fatalf("err = %v, want the error chunk's message", err)
+	}
+	if done {
+		t.Error("a failed candidate was reported as done")
+	}
+}
@@ -0,0 +1,143 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+
+	"github.com/doganarif/giq/internal/config"
+)
+
+func init() {
+	Register("openai", newOpenAIProvider)
+}
+
+const defaultOpenAIModel = openai.GPT4oMini
+
+// openAIProvider talks to the OpenAI Chat Completion API, or to any
+// OpenAI-compatible server (vLLM, LiteLLM, OpenRouter, ...) when base_url is set.
+type openAIProvider struct {
+	client *openai.Client
+	model  string
+}
+
+func newOpenAIProvider(cfg *config.Config) (Provider, error) {
+	// Self-hosted gateways often run without authentication, so the key is
+	// only mandatory when talking to api.openai.com.
+	if cfg.AIKey == "" && cfg.BaseURL == "" {
+		return nil, fmt.Errorf("OpenAI API key is not configured")
+	}
+
+	clientConfig := openai.DefaultConfig(cfg.AIKey)
+	clientConfig.HTTPClient = newHTTPClient(cfg)
+	if cfg.BaseURL != "" {
+		clientConfig.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	}
+
+	p := &openAIProvider{
+		client: openai.NewClientWithConfig(clientConfig),
+		model:  defaultOpenAIModel,
+	}
+	if cfg.Model != "" {
+		p.model = cfg.Model
+	}
+	return p, nil
+}
+
+func (p *openAIProvider) Name() string { return "openai" }
+
+func (p *openAIProvider) Model() string { return p.model }
+
+func (p *openAIProvider) Complete(ctx context.Context, req Request) ([]string, error) {
+	return chatCompletion(ctx, p.client, p.model, req, "OpenAI")
+}
+
+func (p *openAIProvider) Stream(ctx context.Context, req Request, onEvent func(StreamEvent)) ([]string, error) {
+	return chatCompletionStream(ctx, p.client, p.model, req, "OpenAI", onEvent)
+}
+
+// chatCompletion runs a Chat Completion request with a go-openai client. It is
+// shared by every provider that speaks the OpenAI wire format. label is used
+// in error messages to tell the backends apart.
+func chatCompletion(ctx context.Context, client *openai.Client, model string, req Request, label string) ([]string, error) {
+	resp, err := client.CreateChatCompletion(ctx, newChatRequest(model, req))
+	if err != nil {
+		return nil, fmt.Errorf("%s API error: %w", label, err)
+	}
+
+	choices := make([]string, 0, len(resp.Choices))
+	for _, choice := range resp.Choices {
+		choices = append(choices, choice.Message.Content)
+	}
+	choices = trimChoices(choices)
+	if len(choices) == 0 {
+		return nil, fmt.Errorf("no completions returned from %s", label)
+	}
+	return choices, nil
+}
+
+// chatCompletionStream is the streaming counterpart of chatCompletion.
+// Candidates are told apart by the choice index of each chunk.
+func chatCompletionStream(ctx context.Context, client *openai.Client, model string, req Request, label string, onEvent func(StreamEvent)) ([]string, error) {
+	creq := newChatRequest(model, req)
+	creq.Stream = true
+
+	stream, err := client.CreateChatCompletionStream(ctx, creq)
+	if err != nil {
+		return nil, fmt.Errorf("%s API error: %w", label, err)
+	}
+	defer stream.Close()
+
+	var texts []string
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s API error: %w", label, err)
+		}
+		for _, choice := range resp.Choices {
+			if choice.Index < 0 {
+				continue
+			}
+			for len(texts) <= choice.Index {
+				texts = append(texts, "")
+			}
+			texts[choice.Index] += choice.Delta.Content
+			done := choice.FinishReason != ""
+			if choice.Delta.Content != "" || done {
+				onEvent(StreamEvent{Index: choice.Index, Delta: choice.Delta.Content, Done: done})
+			}
+		}
+	}
+
+	choices := trimChoices(texts)
+	if len(choices) == 0 {
+		return nil, fmt.Errorf("no completions returned from %s", label)
+	}
+	return choices, nil
+}
+
+// newChatRequest converts a Request into the go-openai request type.
+func newChatRequest(model string, req Request) openai.ChatCompletionRequest {
+	messages := make([]openai.ChatCompletionMessage, 0, len(req.Messages))
+	for _, m := range req.Messages {
+		messages = append(messages, openai.ChatCompletionMessage{Role: m.Role, Content: m.Content})
+	}
+
+	creq := openai.ChatCompletionRequest{
+		Model:       model,
+		Messages:    messages,
+		Temperature: req.Temperature,
+		MaxTokens:   req.MaxTokens,
+	}
+	if req.N > 1 {
+		creq.N = req.N
+	}
+	return creq
+}
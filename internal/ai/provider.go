@@ -0,0 +1,239 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/doganarif/giq/internal/config"
+)
+
+// Message roles understood by every provider.
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// Message is a single chat message sent to a provider.
+type Message struct {
+	Role    string
+	Content string
+}
+
+// Request describes a chat completion request independently of the backend.
+type Request struct {
+	Messages    []Message
+	Temperature float32
+	MaxTokens   int
+	// N is the number of candidate completions requested. Providers that
+	// cannot return several choices in one call must emulate it.
+	N int
+}
+
+// Provider is implemented by every AI backend giq can talk to.
+type Provider interface {
+	// Name returns the ai_provider value the provider is registered under.
+	Name() string
+	// Model returns the model the provider sends requests to.
+	Model() string
+	// Complete sends the request and returns one trimmed completion per choice.
+	Complete(ctx context.Context, req Request) ([]string, error)
+}
+
+// StreamEvent reports the progress of one candidate of a streamed completion.
+type StreamEvent struct {
+	// Index identifies the candidate, from 0 to Request.N-1.
+	Index int
+	// Delta is the text appended to the candidate since the previous event.
+	Delta string
+	// Done is set once the candidate is complete.
+	Done bool
+}
+
+// Streamer is implemented by providers that can stream their completions.
+// onEvent may be called concurrently when candidates are generated in parallel.
+// Stream returns the same trimmed choices Complete would.
+type Streamer interface {
+	Stream(ctx context.Context, req Request, onEvent func(StreamEvent)) ([]string, error)
+}
+
+// stream streams req through p if it supports streaming. Otherwise every
+// choice is reported as a single, complete event once the call returns.
+func stream(ctx context.Context, p Provider, req Request, onEvent func(StreamEvent)) ([]string, error) {
+	if s, ok := p.(Streamer); ok {
+		return s.Stream(ctx, req, onEvent)
+	}
+	choices, err := p.Complete(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	for i, c := range choices {
+		onEvent(StreamEvent{Index: i, Delta: c, Done: true})
+	}
+	return choices, nil
+}
+
+// Factory builds a Provider from the loaded configuration. It should return
+// an error if the configuration for the provider is incomplete.
+type Factory func(cfg *config.Config) (Provider, error)
+
+var providers = map[string]Factory{}
+
+// Register makes a provider available under the given ai_provider name.
+// It is meant to be called from the init function of the provider's file.
+func Register(name string, factory Factory) {
+	name = strings.ToLower(name)
+	if _, exists := providers[name]; exists {
+		panic(fmt.Sprintf("ai: provider %q registered twice", name))
+	}
+	providers[name] = factory
+}
+
+// Providers returns the sorted names of all registered providers.
+func Providers() []string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewProvider returns the provider selected by the configuration. When
+// ai_providers lists several backends, the returned provider tries them in
+// order until one answers. Every call is bounded by the configured timeout.
+func NewProvider(cfg *config.Config) (Provider, error) {
+	names := providerNames(cfg)
+	chain := &chainProvider{verbose: cfg.Verbose}
+	var errs []error
+	for _, name := range names {
+		p, err := newNamedProvider(cfg, name)
+		if err != nil {
+			// A single provider keeps its error as is so callers can react
+			// to e.g. a missing API key. In a chain, incompletely configured
+			// providers are skipped.
+			if len(names) == 1 {
+				return nil, err
+			}
+			chain.logf("skipping AI provider %s: %v", name, err)
+			errs = append(errs, err)
+			continue
+		}
+		chain.members = append(chain.members, chainMember{provider: p, timeout: providerTimeout(cfg, p.Name())})
+	}
+	if len(chain.members) == 0 {
+		return nil, errors.Join(errs...)
+	}
+	return chain, nil
+}
+
+// Models returns the models of the configured providers in fallback order.
+// Providers that are not fully configured are left out.
+func Models(cfg *config.Config) []string {
+	var models []string
+	for _, name := range providerNames(cfg) {
+		if p, err := newNamedProvider(cfg, name); err == nil {
+			models = append(models, p.Model())
+		}
+	}
+	return models
+}
+
+// providerNames returns the configured provider chain.
+func providerNames(cfg *config.Config) []string {
+	if len(cfg.AIProviders) > 0 {
+		return cfg.AIProviders
+	}
+	return []string{cfg.AIProvider}
+}
+
+// newNamedProvider builds a single registered provider. An empty name selects OpenAI.
+func newNamedProvider(cfg *config.Config, name string) (Provider, error) {
+	key := strings.ToLower(strings.TrimSpace(name))
+	if key == "" {
+		key = "openai"
+	}
+	factory, ok := providers[key]
+	if !ok {
+		return nil, fmt.Errorf("unknown AI provider %q (available: %s)", name, strings.Join(Providers(), ", "))
+	}
+	return factory(cfg)
+}
+
+// trimChoices trims whitespace from each completion and drops empty ones.
+func trimChoices(choices []string) []string {
+	out := make([]string, 0, len(choices))
+	for _, c := range choices {
+		if c = strings.TrimSpace(c); c != "" {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// completeN emulates N candidates for backends that return a single completion
+// per call by issuing n requests in parallel. Partial failures are tolerated;
+// an error is returned only when no request produced a completion.
+func completeN(ctx context.Context, n int, complete func(ctx context.Context) (string, error)) ([]string, error) {
+	return streamN(ctx, n, func(StreamEvent) {}, func(ctx context.Context, _ func(string)) (string, error) {
+		return complete(ctx)
+	})
+}
+
+// streamN is the streaming counterpart of completeN. Each of the n parallel
+// calls receives an emit function that reports deltas for its own candidate.
+func streamN(ctx context.Context, n int, onEvent func(StreamEvent), run func(ctx context.Context, emit func(string)) (string, error)) ([]string, error) {
+	if n < 1 {
+		n = 1
+	}
+
+	results := make([]string, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			emit := func(delta string) {
+				if delta != "" {
+					onEvent(StreamEvent{Index: i, Delta: delta})
+				}
+			}
+			results[i], errs[i] = run(ctx, emit)
+			if errs[i] != nil {
+				// Text streamed before the failure is incomplete.
+				results[i] = ""
+				return
+			}
+			onEvent(StreamEvent{Index: i, Done: true})
+		}(i)
+	}
+	wg.Wait()
+
+	choices := trimChoices(results)
+	if len(choices) == 0 {
+		for _, err := range errs {
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return dedupe(choices), nil
+}
+
+// dedupe removes repeated completions while preserving their order.
+func dedupe(choices []string) []string {
+	seen := make(map[string]bool, len(choices))
+	out := choices[:0]
+	for _, c := range choices {
+		if !seen[c] {
+			seen[c] = true
+			out = append(out, c)
+		}
+	}
+	return out
+}
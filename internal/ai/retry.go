@@ -0,0 +1,153 @@
+package ai
+
+import (
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/doganarif/giq/internal/config"
+)
+
+const (
+	defaultRetryDelay = time.Second
+	maxRetryDelay     = 30 * time.Second
+)
+
+// newHTTPClient returns the HTTP client used by every provider. It retries
+// rate-limited and transient failures according to the configuration.
+func newHTTPClient(cfg *config.Config) *http.Client {
+	return &http.Client{
+		Transport: &retryTransport{
+			base:       http.DefaultTransport,
+			maxRetries: cfg.MaxRetries,
+			baseDelay:  cfg.RetryDelay,
+			verbose:    cfg.Verbose,
+		},
+	}
+}
+
+// retryTransport retries requests that failed with a network error, a 429 or
+// a 5xx response. Delays grow exponentially with jitter, and a Retry-After
+// header sent by the server takes precedence over the computed delay.
+// Retrying stops as soon as the request context is cancelled or the next
+// attempt would start after its deadline.
+type retryTransport struct {
+	base       http.RoundTripper
+	maxRetries int
+	baseDelay  time.Duration
+	verbose    bool
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		r := req
+		if attempt > 0 {
+			var err error
+			if r, err = rewind(req); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := t.base.RoundTrip(r)
+		if attempt >= t.maxRetries || ctx.Err() != nil || !shouldRetry(resp, err) {
+			return resp, err
+		}
+
+		delay := t.backoff(attempt)
+		if resp != nil {
+			if after, ok := retryAfter(resp); ok {
+				delay = after
+			}
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			// Waiting would outlive the caller; report the failure instead.
+			return resp, err
+		}
+
+		if resp != nil {
+			logVerbose(t.verbose, "%s returned %s, retrying in %s", req.URL.Host, resp.Status, delay.Round(time.Millisecond))
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		} else {
+			logVerbose(t.verbose, "request to %s failed (%v), retrying in %s", req.URL.Host, err, delay.Round(time.Millisecond))
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns the jittered delay before retry number attempt+1.
+func (t *retryTransport) backoff(attempt int) time.Duration {
+	base := t.baseDelay
+	if base <= 0 {
+		base = defaultRetryDelay
+	}
+	delay := base << attempt
+	if delay <= 0 || delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	// Use "equal jitter": half fixed, half random.
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// rewind returns a copy of req with a fresh body so it can be sent again.
+func rewind(req *http.Request) (*http.Request, error) {
+	r := req.Clone(req.Context())
+	if req.Body != nil && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		r.Body = body
+	}
+	return r, nil
+}
+
+// shouldRetry reports whether a request that produced resp or err is worth retrying.
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return true
+	case resp.StatusCode == http.StatusNotImplemented:
+		return false
+	case resp.StatusCode >= 500:
+		return true
+	}
+	return false
+}
+
+// retryAfter parses the delay requested by the server. OpenAI and Azure send
+// a millisecond precise retry-after-ms header next to the standard Retry-After,
+// which may hold either seconds or an HTTP date.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if ms, err := strconv.ParseFloat(resp.Header.Get("retry-after-ms"), 64); err == nil && ms >= 0 {
+		return time.Duration(ms * float64(time.Millisecond)), true
+	}
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		d := time.Until(at)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
@@ -0,0 +1,188 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// scriptedServer answers the n-th request with the n-th response of script,
+// repeating the last one, and records the request bodies.
+type scriptedServer struct {
+	*httptest.Server
+	mu     sync.Mutex
+	bodies []string
+}
+
+type scriptedResponse struct {
+	status int
+	header map[string]string
+}
+
+func newScriptedServer(t *testing.T, script ...scriptedResponse) *scriptedServer {
+	t.Helper()
+	s := &scriptedServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		s.bodies = append(s.bodies, string(body))
+		resp := script[min(len(s.bodies), len(script))-1]
+		s.mu.Unlock()
+		for k, v := range resp.header {
+			w.Header().Set(k, v)
+		}
+		w.WriteHeader(resp.status)
+		io.WriteString(w, `{"ok":true}`)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *scriptedServer) attempts() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.bodies)
+}
+
+// send posts a request through a retrying client.
+func send(ctx context.Context, t *testing.T, url string, transport *retryTransport) (*http.Response, error) {
+	t.Helper()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader(`{"prompt":"x"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport.base = http.DefaultTransport
+	return (&http.Client{Transport: transport}).Do(req)
+}
+
+func TestRetryTransportRetriesUntilSuccess(t *testing.T) {
+	srv := newScriptedServer(t,
+		scriptedResponse{status: http.StatusTooManyRequests, header: map[string]string{"Retry-After": "1"}},
+		scriptedResponse{status: http.StatusServiceUnavailable},
+		scriptedResponse{status: http.StatusOK},
+	)
+
+	start := time.Now()
+	resp, err := send(context.Background(), t, srv.URL, &retryTransport{maxRetries: 3, baseDelay: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	if n := srv.attempts(); n != 3 {
+		t.Fatalf("attempts = %d, want 3", n)
+	}
+	// The backoff alone is a few milliseconds; only Retry-After explains a second.
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("elapsed = %s, Retry-After: 1 was not honored", elapsed)
+	}
+	for i, body := range srv.bodies {
+		if body != `{"prompt":"x"}` {
+			t.Errorf("attempt %d sent body %q, want the original body", i+1, body)
+		}
+	}
+}
+
+func TestRetryTransportGivesUpAfterMaxRetries(t *testing.T) {
+	srv := newScriptedServer(t, scriptedResponse{status: http.StatusBadGateway})
+
+	resp, err := send(context.Background(), t, srv.URL, &retryTransport{maxRetries: 2, baseDelay: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Errorf("status = %d, want the last failure", resp.StatusCode)
+	}
+	if n := srv.attempts(); n != 3 {
+		t.Errorf("attempts = %d, want 1 + 2 retries", n)
+	}
+}
+
+func TestRetryTransportDoesNotRetryClientErrors(t *testing.T) {
+	srv := newScriptedServer(t, scriptedResponse{status: http.StatusUnauthorized})
+
+	resp, err := send(context.Background(), t, srv.URL, &retryTransport{maxRetries: 3, baseDelay: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if n := srv.attempts(); n != 1 {
+		t.Errorf("attempts = %d, want 1", n)
+	}
+}
+
+func TestRetryTransportStopsBeforeDeadline(t *testing.T) {
+	srv := newScriptedServer(t,
+		scriptedResponse{status: http.StatusTooManyRequests, header: map[string]string{"Retry-After": "30"}},
+		scriptedResponse{status: http.StatusOK},
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	resp, err := send(ctx, t, srv.URL, &retryTransport{maxRetries: 3, baseDelay: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	// Waiting 30s would outlive the deadline, so the 429 is returned at once.
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("status = %d, want 429", resp.StatusCode)
+	}
+	if n := srv.attempts(); n != 1 {
+		t.Errorf("attempts = %d, want 1", n)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("elapsed = %s, want an immediate return", elapsed)
+	}
+}
+
+func TestRetryTransportStopsWhenCancelled(t *testing.T) {
+	srv := newScriptedServer(t, scriptedResponse{status: http.StatusServiceUnavailable})
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := send(ctx, t, srv.URL, &retryTransport{maxRetries: 3, baseDelay: 10 * time.Second})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if n := srv.attempts(); n != 1 {
+		t.Errorf("attempts = %d, want 1", n)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("elapsed = %s, cancelling did not interrupt the backoff", elapsed)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		header map[string]string
+		want   time.Duration
+		ok     bool
+	}{
+		{map[string]string{"Retry-After": "3"}, 3 * time.Second, true},
+		{map[string]string{"retry-after-ms": "250", "Retry-After": "3"}, 250 * time.Millisecond, true},
+		{map[string]string{"Retry-After": "soon"}, 0, false},
+		{nil, 0, false},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{Header: http.Header{}}
+		for k, v := range tt.header {
+			resp.Header.Set(k, v)
+		}
+		got, ok := retryAfter(resp)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("retryAfter(%v) = %s, %v; want %s, %v", tt.header, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -1,10 +1,12 @@
 package app
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"os/signal"
 	"strings"
 
 	"github.com/doganarif/giq/internal/ai"
@@ -28,9 +30,6 @@ func New() (*App, error) {
 		return nil, fmt.Errorf("git not found in PATH: %w", err)
 	}
 
-	// Load configuration (defaults are used if no config file is found).
-	cfg, _ := config.Load()
-
 	// Attempt to open the git repository.
 	repo, err := git.PlainOpenWithOptions(".", &git.PlainOpenOptions{
 		DetectDotGit: true,
@@ -39,11 +38,33 @@ func New() (*App, error) {
 		return nil, fmt.Errorf("opening repository: %w", err)
 	}
 
-	return &App{
-		Config: cfg,
+	a := &App{
 		Repo:   repo,
 		GitCmd: gitPath,
-	}, nil
+	}
+
+	// Load configuration (defaults are used if no config file is found),
+	// including the repository's own settings.
+	cfg, err := config.Load(a.RepoRoot())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not load configuration: %v\n", err)
+		cfg = &config.Config{}
+	}
+	a.Config = cfg
+
+	return a, nil
+}
+
+// RepoRoot returns the root of the working tree, or "" outside a repository.
+func (a *App) RepoRoot() string {
+	if a.Repo == nil {
+		return ""
+	}
+	w, err := a.Repo.Worktree()
+	if err != nil {
+		return ""
+	}
+	return w.Filesystem.Root()
 }
 
 // ExecGit delegates execution to the system's git executable.
@@ -108,17 +129,54 @@ func (a *App) GenerateCommitMessage() (string, error) {
 		return "No changes detected.", nil
 	}
 
-	// Build a prompt that includes the list of staged files.
-	prompt := fmt.Sprintf(
-		"Generate a single line, concise, and descriptive git commit message summarizing the staged changes on the following files: %s. "+
-			"Do not include bullet points, extra formatting, or multiple lines. Diff:\n%s",
-		trimmedFiles, diff,
-	)
+	prepared, err := a.PrepareDiff(diff)
+	if err != nil {
+		return "", err
+	}
 
-	// Use the AI package to generate the commit message.
-	commitMsg, err := ai.GenerateCommitMessage(a.Config, prompt)
+	// Only one message is needed, so a single candidate is requested; in
+	// conventional mode it is repaired or generated again like in the picker.
+	cfg := *a.Config
+	cfg.Candidates = 1
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	suggestions, err := a.streamCommitMessages(ctx, &cfg, trimmedFiles,
+		ai.CommitConversation(a.CommitPrompt(trimmedFiles, prepared.Text), nil, ""), func(ai.StreamEvent) {})
 	if err != nil {
 		return "", err
 	}
-	return commitMsg, nil
+	if len(suggestions) == 0 {
+		return "", fmt.Errorf("no commit message suggestions returned")
+	}
+	return suggestions[0], nil
+}
+
+// CommitPrompt builds the prompt asking for commit messages for the staged
+// files (one per line) and their prepared diff.
+func (a *App) CommitPrompt(stagedFiles, diff string) string {
+	files := strings.Join(strings.Fields(stagedFiles), ", ")
+
+	var b strings.Builder
+	if a.Config.CommitBody {
+		fmt.Fprintf(&b, "Generate a git commit message summarizing the staged changes on the following files: %s. "+
+			"Start with a concise, descriptive subject line of at most 72 characters, followed by a blank line and a short body, "+
+			"wrapped at 72 columns, that explains why the change was made. ", files)
+	} else {
+		fmt.Fprintf(&b, "Generate a single line, concise, and descriptive git commit message summarizing the staged changes on the following files: %s. ", files)
+	}
+	if a.Conventional() {
+		b.WriteString(conventionalInstructions(InferScope(stagedFiles)))
+	}
+	if style := a.commitStyle(); style != nil {
+		// A failure to load the rules only means the examples go unredacted.
+		rules, _ := a.privacyRules()
+		b.WriteString(style.prompt(rules))
+	}
+	if a.Config.CommitBody {
+		b.WriteString("Do not use Markdown formatting and do not add trailers such as Signed-off-by. Diff:\n")
+	} else {
+		b.WriteString("Do not include bullet points, extra formatting, or multiple lines. Diff:\n")
+	}
+	b.WriteString(diff)
+	return b.String()
 }
@@ -0,0 +1,355 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/doganarif/giq/internal/ai"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Sections of a changelog, in the order they are rendered.
+const (
+	SectionBreaking = "Breaking Changes"
+	SectionFeatures = "Features"
+	SectionFixes    = "Fixes"
+	SectionOther    = "Other"
+)
+
+var changelogSections = []string{SectionBreaking, SectionFeatures, SectionFixes, SectionOther}
+
+var (
+	// breakingFooter matches the footer announcing a breaking change.
+	breakingFooter = regexp.MustCompile(`(?m)^BREAKING[ -]CHANGE:`)
+	// noteLine matches a line of the model's answer: "3. [fix] Note".
+	noteLine = regexp.MustCompile(`(?i)^\W*(?:commit\s*)?(\d+)\W*\[([^\]]*)\]\s*(.*)$`)
+)
+
+// ChangeEntry is a commit as it appears in a changelog.
+type ChangeEntry struct {
+	Hash    plumbing.Hash
+	Subject string
+	Body    string
+	// Type and Scope are set for Conventional Commits.
+	Type  string
+	Scope string
+	// Section is "" until the commit is classified.
+	Section string
+	// Note is the line shown in the changelog.
+	Note string
+}
+
+// Changelog is the list of changes of one release.
+type Changelog struct {
+	// Version is the heading of the release, "Unreleased" if it has no tag.
+	Version string
+	Date    time.Time
+	Entries []ChangeEntry
+}
+
+// ParseRange splits "<from>..<to>" into its refs. A missing <to> is HEAD; a
+// missing <from> means the whole history.
+func ParseRange(spec string) (from, to string) {
+	from, to, found := strings.Cut(spec, "..")
+	if !found {
+		return spec, "HEAD"
+	}
+	if to == "" {
+		to = "HEAD"
+	}
+	return from, to
+}
+
+// CommitsBetween returns the commits reachable from to but not from from,
+// newest first, without merges and fixup commits. An empty from returns the
+// whole history of to.
+func (a *App) CommitsBetween(from, to string) ([]*object.Commit, error) {
+	if a.Repo == nil {
+		return nil, fmt.Errorf("not a git repository")
+	}
+	toHash, err := a.Repo.ResolveRevision(plumbing.Revision(to))
+	if err != nil {
+		return nil, fmt.Errorf("resolving %s: %w", to, err)
+	}
+
+	// Everything the older ref already has is left out.
+	released := make(map[plumbing.Hash]bool)
+	if from != "" {
+		fromHash, err := a.Repo.ResolveRevision(plumbing.Revision(from))
+		if err != nil {
+			return nil, fmt.Errorf("resolving %s: %w", from, err)
+		}
+		iter, err := a.Repo.Log(&git.LogOptions{From: *fromHash})
+		if err != nil {
+			return nil, err
+		}
+		err = iter.ForEach(func(c *object.Commit) error {
+			released[c.Hash] = true
+			return nil
+		})
+		iter.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	iter, err := a.Repo.Log(&git.LogOptions{From: *toHash, Order: git.LogOrderCommitterTime})
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+	var commits []*object.Commit
+	err = iter.ForEach(func(c *object.Commit) error {
+		subject, _ := SplitMessage(c.Message)
+		if released[c.Hash] || c.NumParents() > 1 || isFixupSubject(subject) {
+			return nil
+		}
+		commits = append(commits, c)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return commits, nil
+}
+
+// ClassifyCommit returns the changelog entry of a commit. Conventional
+// Commits are sorted into a section by type; other commits are left
+// unclassified, except for those announcing a breaking change.
+func ClassifyCommit(c *object.Commit) ChangeEntry {
+	subject, body := SplitMessage(c.Message)
+	e := ChangeEntry{Hash: c.Hash, Subject: subject, Body: body, Note: subject}
+	breaking := breakingFooter.MatchString(body)
+
+	if m := conventionalHeader.FindStringSubmatch(subject); m != nil {
+		if typ := normalizeType(m[1]); typ != "" {
+			e.Type, e.Scope, e.Note = typ, strings.TrimSpace(m[2]), capitalize(strings.TrimSpace(m[4]))
+			breaking = breaking || m[3] == "!"
+			switch typ {
+			case "feat":
+				e.Section = SectionFeatures
+			case "fix":
+				e.Section = SectionFixes
+			default:
+				e.Section = SectionOther
+			}
+		}
+	}
+	if breaking {
+		e.Section = SectionBreaking
+	}
+	return e
+}
+
+// capitalize upper-cases the first letter of s.
+func capitalize(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError {
+		return s
+	}
+	return string(unicode.ToUpper(r)) + s[size:]
+}
+
+// DescribeChanges asks the model to rewrite the notes of the entries as
+// release notes for users, and to sort the unclassified ones into a section.
+// Entries the model skips keep their subject as note; unclassified ones it
+// skips go to Other.
+func (a *App) DescribeChanges(entries []ChangeEntry) error {
+	defer func() {
+		for i := range entries {
+			if entries[i].Section == "" {
+				entries[i].Section = SectionOther
+			}
+		}
+	}()
+	if len(entries) == 0 {
+		return nil
+	}
+	rules, err := a.privacyRules()
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString("The following commits make up a release. For each commit, write a one-line release note for users of the project: " +
+		"say what changed for them in plain words, in the imperative mood and without a trailing period. " +
+		"Also classify each commit as feature (new functionality), fix (a bug fix), breaking (a change users must adapt to) " +
+		"or other (anything else, such as refactoring, documentation or tooling). " +
+		"Answer only with one line per commit in this format:\n<commit number>. [<feature|fix|breaking|other>] <release note>\n\n")
+	for i, e := range entries {
+		subject, _ := rules.redactLine(e.Subject)
+		fmt.Fprintf(&b, "%d. %s\n", i+1, subject)
+		if body := strings.TrimSpace(e.Body); body != "" {
+			// The first paragraph of the body usually says why.
+			para, _, _ := strings.Cut(body, "\n\n")
+			para, _ = rules.redactLine(strings.Join(strings.Fields(para), " "))
+			fmt.Fprintf(&b, "   %s\n", para)
+		}
+	}
+
+	answer, err := ai.GenerateText(a.Config, b.String(), 40*len(entries)+64)
+	if err != nil {
+		return err
+	}
+	for _, line := range strings.Split(answer, "\n") {
+		m := noteLine.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		n, _ := strconv.Atoi(m[1])
+		if n < 1 || n > len(entries) {
+			continue
+		}
+		e := &entries[n-1]
+		if note := strings.TrimSuffix(strings.TrimSpace(m[3]), "."); note != "" {
+			e.Note = note
+		}
+		// A conventional type is the author's own classification; keep it.
+		if e.Section == "" {
+			e.Section = sectionOf(m[2])
+		}
+	}
+	return nil
+}
+
+// sectionOf maps the model's label to a section.
+func sectionOf(label string) string {
+	switch strings.ToLower(strings.TrimSpace(label)) {
+	case "breaking":
+		return SectionBreaking
+	case "feature", "feat":
+		return SectionFeatures
+	case "fix":
+		return SectionFixes
+	}
+	return SectionOther
+}
+
+// grouped returns the entries of each section, in rendering order.
+func (c Changelog) grouped() map[string][]ChangeEntry {
+	groups := make(map[string][]ChangeEntry)
+	for _, e := range c.Entries {
+		section := e.Section
+		if section == "" {
+			section = SectionOther
+		}
+		groups[section] = append(groups[section], e)
+	}
+	return groups
+}
+
+// line renders an entry as a list item, with its scope and short hash.
+func (e ChangeEntry) line() string {
+	note := e.Note
+	if e.Scope != "" {
+		note = fmt.Sprintf("**%s:** %s", e.Scope, note)
+	}
+	return fmt.Sprintf("- %s (%s)\n", note, e.Hash.String()[:7])
+}
+
+// Markdown renders the changelog with a section per kind of change.
+func (c Changelog) Markdown() string {
+	var b strings.Builder
+	b.WriteString("## " + c.Version)
+	if !c.Date.IsZero() {
+		b.WriteString(" (" + c.Date.Format("2006-01-02") + ")")
+	}
+	b.WriteString("\n")
+	b.WriteString(c.sections())
+	return b.String()
+}
+
+// sections renders the sections of the changelog, each with a heading.
+func (c Changelog) sections() string {
+	var b strings.Builder
+	groups := c.grouped()
+	for _, section := range changelogSections {
+		if len(groups[section]) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n### %s\n\n", section)
+		for _, e := range groups[section] {
+			b.WriteString(e.line())
+		}
+	}
+	return b.String()
+}
+
+// KeepAChangelog renders the changelog in the Keep a Changelog format
+// (https://keepachangelog.com): features are Added, fixes Fixed, and
+// breaking and other changes Changed, breaking ones first and marked.
+func (c Changelog) KeepAChangelog() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## [%s]", c.Version)
+	if !c.Date.IsZero() && c.Version != "Unreleased" {
+		b.WriteString(" - " + c.Date.Format("2006-01-02"))
+	}
+	b.WriteString("\n")
+	groups := c.grouped()
+
+	write := func(heading string, entries []ChangeEntry, prefix string) {
+		if len(entries) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "\n### %s\n\n", heading)
+		for _, e := range entries {
+			e.Note = prefix + e.Note
+			b.WriteString(e.line())
+		}
+	}
+	write("Added", groups[SectionFeatures], "")
+	write("Fixed", groups[SectionFixes], "")
+	if len(groups[SectionBreaking])+len(groups[SectionOther]) > 0 {
+		b.WriteString("\n### Changed\n\n")
+		for _, e := range groups[SectionBreaking] {
+			e.Note = "**Breaking:** " + e.Note
+			b.WriteString(e.line())
+		}
+		for _, e := range groups[SectionOther] {
+			b.WriteString(e.line())
+		}
+	}
+	return b.String()
+}
+
+// changelogHeader starts a new CHANGELOG.md.
+const changelogHeader = "# Changelog\n\nAll notable changes to this project will be documented in this file.\n"
+
+// PrependChangelog adds notes to the changelog file at path above the
+// previous releases, below the file's title and introduction. The file is
+// created if it does not exist.
+func PrependChangelog(path, notes string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	notes = strings.TrimSpace(notes) + "\n"
+	existing := string(data)
+	if strings.TrimSpace(existing) == "" {
+		existing = changelogHeader
+	}
+
+	// Insert before the first release heading, or at the end if there is none.
+	head, rest := existing, ""
+	if i := strings.Index(existing, "\n## "); i >= 0 {
+		head, rest = existing[:i+1], existing[i+1:]
+	} else if strings.HasPrefix(existing, "## ") {
+		head, rest = "", existing
+	}
+	head = strings.TrimRight(head, "\n")
+	if head != "" {
+		head += "\n\n"
+	}
+	if rest != "" {
+		notes += "\n"
+	}
+	return os.WriteFile(path, []byte(head+notes+rest), 0644)
+}
@@ -0,0 +1,165 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// DiffOptions selects the changes a "git commit" invocation will record.
+type DiffOptions struct {
+	// All stages modified and deleted tracked files first, like "commit -a".
+	All bool
+	// Amend includes the changes of HEAD, which the new commit replaces.
+	Amend bool
+	// Pathspecs restricts the commit to these paths, taken from the working
+	// tree, like "commit -- <pathspec>". With Include they are added to the
+	// staged changes instead, like "commit -i".
+	Pathspecs []string
+	Include   bool
+}
+
+// CommitDiff returns the diff and the names of the files (one per line) that
+// "git commit" would record with opts. The real index is never modified:
+// -a and pathspecs are applied to a temporary copy of it.
+func (a *App) CommitDiff(opts DiffOptions) (diff, files string, err error) {
+	if a.Repo == nil {
+		return "", "", fmt.Errorf("not a git repository")
+	}
+
+	var env []string
+	if opts.All || len(opts.Pathspecs) > 0 {
+		index, err := a.commitIndex(opts)
+		if index != "" {
+			defer os.Remove(index)
+		}
+		if err != nil {
+			return "", "", err
+		}
+		env = []string{"GIT_INDEX_FILE=" + index}
+	}
+
+	args := []string{"diff", "--cached"}
+	if opts.Amend {
+		base, err := a.amendBase()
+		if err != nil {
+			return "", "", err
+		}
+		args = append(args, base)
+	}
+
+	diff, err = a.gitOutput(env, args...)
+	if err != nil {
+		return "", "", err
+	}
+	files, err = a.gitOutput(env, append(args, "--name-only")...)
+	if err != nil {
+		return "", "", err
+	}
+	return diff, files, nil
+}
+
+// commitIndex builds a temporary index holding what the commit will record
+// and returns its path.
+func (a *App) commitIndex(opts DiffOptions) (string, error) {
+	index, env, err := tempIndex()
+	if err != nil {
+		return "", err
+	}
+
+	if len(opts.Pathspecs) > 0 && !opts.Include {
+		// Only the given paths are committed, on top of HEAD.
+		if a.hasCommit("HEAD") {
+			if _, err := a.gitOutput(env, "read-tree", "HEAD"); err != nil {
+				return index, err
+			}
+		}
+	} else if err := a.copyIndex(index); err != nil {
+		return index, err
+	}
+
+	if opts.All {
+		_, err = a.gitOutput(env, "add", "--update")
+	} else {
+		_, err = a.gitOutput(env, append([]string{"add", "--"}, opts.Pathspecs...)...)
+	}
+	return index, err
+}
+
+// tempIndex reserves a path for a temporary index and returns it with the
+// environment that makes git use it. The caller removes the file.
+func tempIndex() (index string, env []string, err error) {
+	tmp, err := os.CreateTemp("", "giq-index-*")
+	if err != nil {
+		return "", nil, err
+	}
+	index = tmp.Name()
+	tmp.Close()
+	// git refuses to read an empty index file, so start without one.
+	os.Remove(index)
+	return index, []string{"GIT_INDEX_FILE=" + index}, nil
+}
+
+// copyIndex copies the repository's index to dst, if there is one.
+func (a *App) copyIndex(dst string) error {
+	path, err := a.gitPath("index")
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		// No index yet, nothing is staged.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, data, 0644)
+}
+
+// amendBase returns what an amended HEAD is compared against: its parent, or
+// the empty tree for a root commit.
+func (a *App) amendBase() (string, error) {
+	if !a.hasCommit("HEAD") {
+		return "", fmt.Errorf("nothing to amend, there are no commits yet")
+	}
+	if a.hasCommit("HEAD~1") {
+		return "HEAD~1", nil
+	}
+	tree, err := a.gitOutput(nil, "hash-object", "-t", "tree", os.DevNull)
+	return strings.TrimSpace(tree), err
+}
+
+// hasCommit reports whether rev names an existing commit.
+func (a *App) hasCommit(rev string) bool {
+	return exec.Command(a.GitCmd, "rev-parse", "--verify", "--quiet", rev+"^{commit}").Run() == nil
+}
+
+// gitOutput runs git with args and extra environment variables and returns
+// its standard output. The error includes git's message on failure.
+func (a *App) gitOutput(env []string, args ...string) (string, error) {
+	cmd := exec.Command(a.GitCmd, args...)
+	cmd.Env = append(os.Environ(), env...)
+	out, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", err
+	}
+	return string(out), nil
+}
+
+// gitPath returns the absolute path of name inside the git directory, as
+// resolved by "git rev-parse --git-path", which honors core.hooksPath and
+// linked worktrees.
+func (a *App) gitPath(name string) (string, error) {
+	out, err := a.gitOutput(nil, "rev-parse", "--git-path", name)
+	if err != nil {
+		return "", err
+	}
+	// The path is relative to the current directory.
+	return filepath.Abs(strings.TrimSpace(out))
+}
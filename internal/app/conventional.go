@@ -0,0 +1,276 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/doganarif/giq/internal/ai"
+	"github.com/doganarif/giq/internal/config"
+)
+
+// ConventionalStyle is the commit_style value selecting Conventional Commits.
+const ConventionalStyle = "conventional"
+
+// conventionalTypes are the commit types accepted in conventional mode.
+var conventionalTypes = []string{"feat", "fix", "docs", "style", "refactor", "perf", "test", "build", "ci", "chore", "revert"}
+
+// typeAliases maps common misspellings of a type to the proper one.
+var typeAliases = map[string]string{
+	"feature":       "feat",
+	"features":      "feat",
+	"bug":           "fix",
+	"bugfix":        "fix",
+	"hotfix":        "fix",
+	"doc":           "docs",
+	"documentation": "docs",
+	"tests":         "test",
+	"testing":       "test",
+	"refactoring":   "refactor",
+	"performance":   "perf",
+	"chores":        "chore",
+}
+
+// verbTypes maps the leading verb of a plain subject to the type it implies.
+// Subjects starting with other verbs cannot be repaired reliably.
+var verbTypes = map[string]string{
+	"add":         "feat",
+	"implement":   "feat",
+	"introduce":   "feat",
+	"support":     "feat",
+	"allow":       "feat",
+	"enable":      "feat",
+	"fix":         "fix",
+	"resolve":     "fix",
+	"correct":     "fix",
+	"prevent":     "fix",
+	"refactor":    "refactor",
+	"rename":      "refactor",
+	"move":        "refactor",
+	"simplify":    "refactor",
+	"extract":     "refactor",
+	"restructure": "refactor",
+	"remove":      "refactor",
+	"document":    "docs",
+	"test":        "test",
+	"optimize":    "perf",
+	"speed":       "perf",
+	"bump":        "build",
+	"upgrade":     "build",
+	"format":      "style",
+	"reformat":    "style",
+	"revert":      "revert",
+}
+
+// genericDirs are path segments that say nothing about the area of a change.
+var genericDirs = map[string]bool{"internal": true, "pkg": true, "src": true, "lib": true}
+
+var (
+	// conventionalHeader loosely matches "type(scope)!: subject" so that
+	// sloppy output can be repaired.
+	conventionalHeader = regexp.MustCompile(`^([A-Za-z]+)(?:\s*\(([^()]*)\))?(!)?\s*:\s*(.*)$`)
+	// validConventional matches a header that follows the specification.
+	validConventional = regexp.MustCompile(`^(?:` + strings.Join(conventionalTypes, "|") + `)(?:\([a-z0-9._/-]+\))?!?: \S.*$`)
+)
+
+// Conventional reports whether commit messages must follow Conventional Commits.
+func (a *App) Conventional() bool {
+	return strings.EqualFold(strings.TrimSpace(a.Config.CommitStyle), ConventionalStyle)
+}
+
+// InferScope derives a Conventional Commits scope from the staged file names,
+// one per line. The scope is the first directory below generic ones such as
+// internal/ or pkg/, so internal/ai/openai.go yields "ai". Files in the
+// repository root are ignored; if the remaining files disagree, there is no scope.
+func InferScope(stagedFiles string) string {
+	scope := ""
+	for _, file := range strings.Split(strings.TrimSpace(stagedFiles), "\n") {
+		dir := path.Dir(strings.TrimSpace(file))
+		if dir == "." || dir == "" {
+			continue
+		}
+		s := ""
+		for _, segment := range strings.Split(dir, "/") {
+			if !genericDirs[segment] {
+				s = strings.ToLower(segment)
+				break
+			}
+		}
+		if s == "" {
+			continue
+		}
+		if scope != "" && scope != s {
+			return ""
+		}
+		scope = s
+	}
+	return scope
+}
+
+// ValidConventional reports whether msg starts with a Conventional Commits header.
+func ValidConventional(msg string) bool {
+	header, _, _ := strings.Cut(strings.TrimSpace(msg), "\n")
+	return validConventional.MatchString(header)
+}
+
+// RepairConventional turns the subject of a suggestion into a Conventional
+// Commits header, fixing the type spelling, a missing scope and the subject's
+// case, or deriving the type from the leading verb. The body, if any, is kept.
+// It returns false if the suggestion cannot be repaired.
+func RepairConventional(msg, scope string) (string, bool) {
+	subject, body := SplitMessage(msg)
+	header, ok := repairHeader(subject, scope)
+	if !ok {
+		return "", false
+	}
+	if body != "" {
+		header += "\n\n" + body
+	}
+	return header, true
+}
+
+// repairHeader is RepairConventional for the subject line alone.
+func repairHeader(line, scope string) (string, bool) {
+	line = strings.Trim(line, "`\"'")
+
+	if m := conventionalHeader.FindStringSubmatch(line); m != nil {
+		typ := normalizeType(m[1])
+		if typ == "" {
+			return "", false
+		}
+		s := strings.ToLower(strings.TrimSpace(m[2]))
+		if s == "" {
+			s = scope
+		}
+		return formatConventional(typ, s, m[3] != "", m[4])
+	}
+
+	verb, _, _ := strings.Cut(line, " ")
+	typ, ok := verbTypes[strings.ToLower(strings.TrimRight(verb, ":,"))]
+	if !ok {
+		return "", false
+	}
+	return formatConventional(typ, scope, false, line)
+}
+
+// normalizeType returns the canonical form of typ, or "" if it is unknown.
+func normalizeType(typ string) string {
+	typ = strings.ToLower(typ)
+	if alias, ok := typeAliases[typ]; ok {
+		return alias
+	}
+	for _, t := range conventionalTypes {
+		if t == typ {
+			return t
+		}
+	}
+	return ""
+}
+
+// formatConventional builds a header and checks it against the specification.
+func formatConventional(typ, scope string, breaking bool, subject string) (string, bool) {
+	subject = strings.TrimSuffix(strings.TrimSpace(subject), ".")
+	if subject == "" {
+		return "", false
+	}
+	// Lower-case the first letter unless the word is an acronym such as "API".
+	runes := []rune(subject)
+	if len(runes) < 2 || !unicode.IsUpper(runes[1]) {
+		runes[0] = unicode.ToLower(runes[0])
+	}
+
+	var b strings.Builder
+	b.WriteString(typ)
+	if scope != "" {
+		fmt.Fprintf(&b, "(%s)", scope)
+	}
+	if breaking {
+		b.WriteString("!")
+	}
+	b.WriteString(": ")
+	b.WriteString(string(runes))
+
+	header := b.String()
+	return header, ValidConventional(header)
+}
+
+// conventionalAttempts is how often suggestions are generated before giving
+// up when none of them can be turned into a conventional commit.
+const conventionalAttempts = 2
+
+// StreamCommitMessages streams commit message suggestions for a conversation
+// built by ai.CommitConversation through onEvent, like
+// ai.StreamCommitConversation. In conventional mode, suggestions are only
+// reported once complete and valid: invalid ones are repaired or dropped, and
+// if none is left the suggestions are generated again.
+func (a *App) StreamCommitMessages(ctx context.Context, stagedFiles string, messages []ai.Message, onEvent func(ai.StreamEvent)) ([]string, error) {
+	return a.streamCommitMessages(ctx, a.Config, stagedFiles, messages, onEvent)
+}
+
+// streamCommitMessages is StreamCommitMessages with the request settings of cfg.
+func (a *App) streamCommitMessages(ctx context.Context, cfg *config.Config, stagedFiles string, messages []ai.Message, onEvent func(ai.StreamEvent)) ([]string, error) {
+	if !a.Conventional() {
+		return ai.StreamCommitConversation(ctx, cfg, messages, onEvent)
+	}
+
+	// The reminder added on a retry must not leak into the caller's messages.
+	messages = append([]ai.Message(nil), messages...)
+	scope := InferScope(stagedFiles)
+	var mu sync.Mutex
+	var accepted []string
+	seen := make(map[string]bool)
+	accept := func(raw string) {
+		msg, ok := RepairConventional(raw, scope)
+		mu.Lock()
+		defer mu.Unlock()
+		if !ok || seen[msg] {
+			return
+		}
+		seen[msg] = true
+		onEvent(ai.StreamEvent{Index: len(accepted), Delta: msg, Done: true})
+		accepted = append(accepted, msg)
+	}
+
+	for attempt := 0; attempt < conventionalAttempts; attempt++ {
+		if attempt > 0 {
+			last := &messages[len(messages)-1]
+			last.Content += "\n\nThe previous answers did not follow the Conventional Commits format. " +
+				"Start the answer with a line of the form type(scope): subject."
+		}
+
+		var partialMu sync.Mutex
+		partial := make(map[int]string)
+		suggestions, err := ai.StreamCommitConversation(ctx, cfg, messages, func(ev ai.StreamEvent) {
+			partialMu.Lock()
+			partial[ev.Index] += ev.Delta
+			text := partial[ev.Index]
+			partialMu.Unlock()
+			if ev.Done {
+				accept(text)
+			}
+		})
+		for _, s := range suggestions {
+			accept(s)
+		}
+		if err != nil || len(accepted) > 0 {
+			return accepted, err
+		}
+	}
+	return nil, fmt.Errorf("no suggestion followed the Conventional Commits format")
+}
+
+// conventionalInstructions tells the model how to format the subject line.
+func conventionalInstructions(scope string) string {
+	s := "Use the Conventional Commits format type(scope): subject, where type is one of " +
+		strings.Join(conventionalTypes, ", ") + ". "
+	if scope != "" {
+		s += fmt.Sprintf("Use the scope %q. ", scope)
+	} else {
+		s += "Omit the scope, i.e. type: subject. "
+	}
+	return s + "Write the subject in the imperative mood, starting with a lower-case letter and without a trailing period. "
+}
@@ -0,0 +1,318 @@
+package app
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/doganarif/giq/internal/ai"
+)
+
+// defaultDiffBudget is the approximate number of diff tokens sent to the
+// model when neither diff_budget nor a per-model budget is configured.
+const defaultDiffBudget = 8000
+
+// lockfiles are dependency manifests whose content is of no use to the model.
+var lockfiles = map[string]bool{
+	"package-lock.json":   true,
+	"npm-shrinkwrap.json": true,
+	"yarn.lock":           true,
+	"pnpm-lock.yaml":      true,
+	"bun.lockb":           true,
+	"go.sum":              true,
+	"Cargo.lock":          true,
+	"Gemfile.lock":        true,
+	"composer.lock":       true,
+	"poetry.lock":         true,
+	"Pipfile.lock":        true,
+	"uv.lock":             true,
+	"flake.lock":          true,
+	"mix.lock":            true,
+	"pubspec.lock":        true,
+	"Podfile.lock":        true,
+	"packages.lock.json":  true,
+}
+
+// PreparedDiff is a staged diff reduced to fit the prompt budget.
+type PreparedDiff struct {
+	Text string
+	// OriginalTokens and Tokens are estimates before and after preparation.
+	OriginalTokens int
+	Tokens         int
+	// Summarized lists the files whose content was replaced by a summary.
+	Summarized []string
+	// Excluded lists the files matched by exclude_paths, and Redactions
+	// counts the values masked by the redaction patterns.
+	Excluded   []string
+	Redactions int
+}
+
+// Reduced reports whether the diff was changed during preparation.
+func (d PreparedDiff) Reduced() bool {
+	return d.Tokens < d.OriginalTokens
+}
+
+// PrepareDiff turns a raw diff into the text that is sent to the AI. Files
+// matched by exclude_paths are reduced to their name and secrets matched by
+// the redaction patterns are masked. The diff is then condensed so that it
+// fits the token budget of the configured model. Low-value files (lockfiles,
+// minified, vendored and binary files) are summarized and whitespace-only
+// hunks are collapsed. If the diff is still too large, the largest files are
+// replaced by line counts until it fits, and as a last resort the diff is cut
+// off.
+func (a *App) PrepareDiff(diff string) (PreparedDiff, error) {
+	rules, err := a.privacyRules()
+	if err != nil {
+		return PreparedDiff{}, err
+	}
+
+	budget := a.diffBudget()
+	prepared := PreparedDiff{OriginalTokens: estimateTokens(diff)}
+
+	files := parseDiff(diff)
+	for _, f := range files {
+		if rules.excluded(f.path) {
+			f.summarize("content excluded by exclude_paths")
+			prepared.Excluded = append(prepared.Excluded, f.path)
+			continue
+		}
+		prepared.Redactions += rules.redact(f)
+
+		if reason := f.lowValueReason(); reason != "" {
+			f.summarize(reason)
+			prepared.Summarized = append(prepared.Summarized, f.path)
+			continue
+		}
+		f.collapseWhitespaceHunks()
+	}
+
+	if estimateTokens(renderDiff(files)) > budget {
+		// Summarize the largest files first, they free the most room.
+		bySize := make([]*fileDiff, len(files))
+		copy(bySize, files)
+		sort.SliceStable(bySize, func(i, j int) bool {
+			return len(bySize[i].String()) > len(bySize[j].String())
+		})
+		for _, f := range bySize {
+			if estimateTokens(renderDiff(files)) <= budget {
+				break
+			}
+			if !f.summarized {
+				f.summarize("content omitted to fit the model's context")
+				prepared.Summarized = append(prepared.Summarized, f.path)
+			}
+		}
+	}
+
+	prepared.Text = renderDiff(files)
+	if estimateTokens(prepared.Text) > budget {
+		prepared.Text = truncateToTokens(prepared.Text, budget)
+	}
+	prepared.Tokens = estimateTokens(prepared.Text)
+	return prepared, nil
+}
+
+// diffBudget returns the smallest budget among the configured models, so that
+// the diff fits whichever provider of a fallback chain ends up answering.
+func (a *App) diffBudget() int {
+	global := a.Config.DiffBudget
+	if global <= 0 {
+		global = defaultDiffBudget
+	}
+
+	budget := 0
+	for _, model := range ai.Models(a.Config) {
+		b := global
+		if perModel, ok := a.Config.DiffBudgets[strings.ToLower(model)]; ok && perModel > 0 {
+			b = perModel
+		}
+		if budget == 0 || b < budget {
+			budget = b
+		}
+	}
+	if budget == 0 {
+		budget = global
+	}
+	return budget
+}
+
+// estimateTokens approximates the token count of s. Roughly four characters
+// per token holds well enough for code and English text across tokenizers.
+func estimateTokens(s string) int {
+	return (len(s) + 3) / 4
+}
+
+// truncateToTokens cuts s at a line boundary so that it fits budget tokens.
+func truncateToTokens(s string, budget int) string {
+	const note = "\n... (diff truncated to fit the model's context)\n"
+	limit := budget*4 - len(note)
+	if limit <= 0 {
+		return strings.TrimPrefix(note, "\n")
+	}
+	if len(s) <= limit {
+		return s
+	}
+	cut := s[:limit]
+	if i := strings.LastIndexByte(cut, '\n'); i > 0 {
+		cut = cut[:i]
+	}
+	return cut + note
+}
+
+// fileDiff is the part of a unified diff that belongs to a single file.
+type fileDiff struct {
+	path       string
+	header     []string
+	hunks      []*hunk
+	added      int
+	deleted    int
+	binary     bool
+	summarized bool
+}
+
+// hunk is a single "@@" section of a file diff.
+type hunk struct {
+	header string
+	lines  []string
+}
+
+// parseDiff splits the output of "git diff" into per-file sections.
+func parseDiff(diff string) []*fileDiff {
+	var files []*fileDiff
+	var current *fileDiff
+	var currentHunk *hunk
+
+	for _, line := range strings.Split(strings.TrimRight(diff, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			current = &fileDiff{path: diffPath(line), header: []string{line}}
+			currentHunk = nil
+			files = append(files, current)
+		case current == nil:
+			// Ignore anything before the first file header.
+		case strings.HasPrefix(line, "@@"):
+			currentHunk = &hunk{header: line}
+			current.hunks = append(current.hunks, currentHunk)
+		case currentHunk == nil:
+			if strings.HasPrefix(line, "Binary files ") || line == "GIT binary patch" {
+				current.binary = true
+			}
+			current.header = append(current.header, line)
+		default:
+			currentHunk.lines = append(currentHunk.lines, line)
+			if strings.HasPrefix(line, "+") {
+				current.added++
+			} else if strings.HasPrefix(line, "-") {
+				current.deleted++
+			}
+		}
+	}
+	return files
+}
+
+// diffPath extracts the destination path from a "diff --git a/x b/x" line.
+func diffPath(line string) string {
+	rest := strings.TrimPrefix(line, "diff --git ")
+	if i := strings.LastIndex(rest, " b/"); i >= 0 {
+		return rest[i+3:]
+	}
+	return rest
+}
+
+// lowValueReason returns why the file's content should not be sent to the
+// model, or "" if it should.
+func (f *fileDiff) lowValueReason() string {
+	base := path.Base(f.path)
+	switch {
+	case f.binary:
+		return "binary file changed"
+	case lockfiles[base]:
+		return "lockfile updated"
+	case strings.HasSuffix(base, ".min.js"), strings.HasSuffix(base, ".min.css"), strings.HasSuffix(base, ".map"):
+		return "minified file changed"
+	case strings.HasPrefix(f.path, "vendor/"), strings.Contains(f.path, "/vendor/"),
+		strings.HasPrefix(f.path, "node_modules/"), strings.Contains(f.path, "/node_modules/"):
+		return "vendored file changed"
+	}
+	for _, h := range f.hunks {
+		for _, l := range h.lines {
+			// Minified or generated files tend to have enormous lines.
+			if len(l) > 1000 {
+				return "minified file changed"
+			}
+		}
+	}
+	return ""
+}
+
+// summarize replaces the file's hunks with a one-line summary.
+func (f *fileDiff) summarize(reason string) {
+	f.summarized = true
+	f.header = []string{f.header[0], fmt.Sprintf("(%s: +%d -%d lines)", reason, f.added, f.deleted)}
+	f.hunks = nil
+}
+
+// collapseWhitespaceHunks replaces hunks that only change whitespace with a note.
+func (f *fileDiff) collapseWhitespaceHunks() {
+	for _, h := range f.hunks {
+		var removed, added strings.Builder
+		for _, l := range h.lines {
+			if strings.HasPrefix(l, "-") {
+				removed.WriteString(stripSpace(l[1:]))
+			} else if strings.HasPrefix(l, "+") {
+				added.WriteString(stripSpace(l[1:]))
+			}
+		}
+		if hasChanges(h) && removed.String() == added.String() {
+			h.lines = []string{"(whitespace-only changes)"}
+		}
+	}
+}
+
+// hasChanges reports whether the hunk adds or removes any line.
+func hasChanges(h *hunk) bool {
+	for _, l := range h.lines {
+		if strings.HasPrefix(l, "+") || strings.HasPrefix(l, "-") {
+			return true
+		}
+	}
+	return false
+}
+
+// stripSpace removes all whitespace from s.
+func stripSpace(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+func (f *fileDiff) String() string {
+	var b strings.Builder
+	for _, l := range f.header {
+		b.WriteString(l)
+		b.WriteByte('\n')
+	}
+	for _, h := range f.hunks {
+		b.WriteString(h.header)
+		b.WriteByte('\n')
+		for _, l := range h.lines {
+			b.WriteString(l)
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// renderDiff joins the file sections back into a single diff.
+func renderDiff(files []*fileDiff) string {
+	var b strings.Builder
+	for _, f := range files {
+		b.WriteString(f.String())
+	}
+	return b.String()
+}
@@ -0,0 +1,48 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// editorHelp is appended to a message opened in the editor.
+const editorHelp = "\n\n# Edit the commit message above. Lines starting with '#' are ignored,\n# and an empty message discards the edit.\n"
+
+// EditorCommand returns a command that opens path in the user's editor,
+// chosen the way git does from $GIT_EDITOR, core.editor, $VISUAL and $EDITOR.
+func (a *App) EditorCommand(path string) (*exec.Cmd, error) {
+	out, err := exec.Command(a.GitCmd, "var", "GIT_EDITOR").Output()
+	if err != nil {
+		return nil, fmt.Errorf("finding the git editor: %w", err)
+	}
+	editor := strings.TrimSpace(string(out))
+	if editor == "" {
+		return nil, fmt.Errorf("no editor configured")
+	}
+	// Like git, run the editor through the shell since it may include arguments.
+	return exec.Command("sh", "-c", editor+` "$@"`, editor, path), nil
+}
+
+// WriteMessageFile writes msg to a temporary file for editing and returns its path.
+func WriteMessageFile(msg string) (string, error) {
+	return writeTempFile("giq-COMMIT_EDITMSG-*", strings.TrimSpace(msg)+editorHelp)
+}
+
+// ReadMessageFile reads an edited message back, dropping comment lines, and
+// removes the file.
+func ReadMessageFile(path string) (string, error) {
+	defer os.Remove(path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	var lines []string
+	for _, l := range strings.Split(string(data), "\n") {
+		if !strings.HasPrefix(l, "#") {
+			lines = append(lines, strings.TrimRight(l, " \t\r"))
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n")), nil
+}
@@ -0,0 +1,173 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CommitMsgHook is the git hook giq installs to suggest commit messages.
+const CommitMsgHook = "prepare-commit-msg"
+
+// hookMarker identifies a hook script written by giq.
+const hookMarker = "# Installed by giq"
+
+// chainedHookSuffix is appended to the name of a pre-existing hook that giq's
+// hook runs before its own suggestion.
+const chainedHookSuffix = ".pre-giq"
+
+// hookScript is the prepare-commit-msg hook. It runs the hook it replaced
+// first, and never lets giq failures abort the commit.
+const hookScript = `#!/bin/sh
+` + hookMarker + `. Run "giq hook uninstall" to remove it.
+chained="$0` + chainedHookSuffix + `"
+if [ -x "$chained" ]; then
+	"$chained" "$@" || exit $?
+fi
+giq=%s
+[ -x "$giq" ] || giq=giq
+"$giq" hook ` + CommitMsgHook + ` "$@" || true
+`
+
+// HooksDir returns the directory git runs hooks from, honoring core.hooksPath.
+func (a *App) HooksDir() (string, error) {
+	if a.Repo == nil {
+		return "", fmt.Errorf("not a git repository")
+	}
+	dir, err := a.gitPath("hooks")
+	if err != nil {
+		return "", fmt.Errorf("locating the hooks directory: %w", err)
+	}
+	return dir, nil
+}
+
+// InstallHook installs the prepare-commit-msg hook. An existing hook that was
+// not written by giq is kept and run before giq's suggestion. It returns the
+// path of the chained hook, if any.
+func (a *App) InstallHook() (chained string, err error) {
+	dir, err := a.HooksDir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, CommitMsgHook)
+	chainedPath := path + chainedHookSuffix
+	existing, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		// Nothing to keep.
+	case err != nil:
+		return "", err
+	case !isGiqHook(existing):
+		if _, err := os.Stat(chainedPath); err == nil {
+			return "", fmt.Errorf("%s already exists, remove it or the %s hook first", chainedPath, CommitMsgHook)
+		}
+		if err := os.Rename(path, chainedPath); err != nil {
+			return "", err
+		}
+	}
+	if _, err := os.Stat(chainedPath); err == nil {
+		chained = chainedPath
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		exe = "giq"
+	}
+	script := fmt.Sprintf(hookScript, shellQuote(exe))
+	return chained, os.WriteFile(path, []byte(script), 0755)
+}
+
+// UninstallHook removes giq's prepare-commit-msg hook and restores the hook
+// it replaced, if any.
+func (a *App) UninstallHook() error {
+	dir, err := a.HooksDir()
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(dir, CommitMsgHook)
+	existing, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("no %s hook is installed", CommitMsgHook)
+	}
+	if err != nil {
+		return err
+	}
+	if !isGiqHook(existing) {
+		return fmt.Errorf("the %s hook was not installed by giq", CommitMsgHook)
+	}
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+
+	chained := path + chainedHookSuffix
+	if _, err := os.Stat(chained); err == nil {
+		return os.Rename(chained, path)
+	}
+	return nil
+}
+
+// isGiqHook reports whether a hook script was written by InstallHook.
+func isGiqHook(script []byte) bool {
+	return strings.Contains(string(script), hookMarker)
+}
+
+// shellQuote quotes s for use as a single word in a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// PrependMessage writes msg at the top of the commit message file at path,
+// keeping the comments git put there.
+func PrependMessage(path, msg string) error {
+	existing, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(msg+"\n"+string(existing)), 0644)
+}
+
+// AddTicketsToFile adds the branch's ticket IDs to the message in the commit
+// message file at path, keeping any block of comment lines git put after it.
+func (a *App) AddTicketsToFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	lines := strings.Split(string(data), "\n")
+	end := len(lines)
+	for end > 0 && (strings.TrimSpace(lines[end-1]) == "" || strings.HasPrefix(lines[end-1], "#")) {
+		end--
+	}
+	msg := strings.Join(lines[:end], "\n")
+	if strings.TrimSpace(msg) == "" {
+		return nil
+	}
+	updated, err := a.AddTickets(msg)
+	if err != nil || updated == msg {
+		return err
+	}
+	rest := strings.Join(lines[end:], "\n")
+	return os.WriteFile(path, []byte(updated+"\n"+rest), 0644)
+}
+
+// HasMessage reports whether the commit message file at path already has
+// content other than comments and blank lines.
+func HasMessage(path string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	for _, l := range strings.Split(string(data), "\n") {
+		if l = strings.TrimSpace(l); l != "" && !strings.HasPrefix(l, "#") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,146 @@
+package app
+
+import (
+	"regexp"
+	"strings"
+)
+
+// bodyWidth is the column at which commit message bodies are wrapped.
+const bodyWidth = 72
+
+var (
+	// trailerLine matches a git trailer such as "Signed-off-by: Jane <j@x.org>".
+	trailerLine = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]*: \S`)
+	// listItem matches the start of a bullet or numbered list item.
+	listItem = regexp.MustCompile(`^(?:[-*+]|\d+[.)])\s+`)
+)
+
+// SplitMessage returns the subject line of a commit message and its body,
+// which includes any trailers.
+func SplitMessage(msg string) (subject, body string) {
+	subject, body, _ = strings.Cut(strings.TrimSpace(msg), "\n")
+	return strings.TrimSpace(subject), strings.TrimSpace(body)
+}
+
+// FormatCommitMessage normalizes a generated commit message: the subject is
+// separated from the body by a blank line, body paragraphs and list items are
+// wrapped at 72 columns, and a trailing block of trailers (Signed-off-by,
+// Co-authored-by, Refs, ...) is kept intact as the last paragraph.
+func FormatCommitMessage(msg string) string {
+	subject, body := SplitMessage(stripCodeFence(msg))
+	if body == "" {
+		return subject
+	}
+
+	paragraphs := splitParagraphs(body)
+	var trailers []string
+	if last := paragraphs[len(paragraphs)-1]; isTrailerBlock(last) {
+		trailers = last
+		paragraphs = paragraphs[:len(paragraphs)-1]
+	}
+
+	parts := []string{subject}
+	for _, p := range paragraphs {
+		parts = append(parts, wrapParagraph(p))
+	}
+	if len(trailers) > 0 {
+		parts = append(parts, strings.Join(trailers, "\n"))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// stripCodeFence removes a Markdown code fence around the whole message.
+func stripCodeFence(msg string) string {
+	lines := strings.Split(strings.TrimSpace(msg), "\n")
+	if len(lines) >= 2 && strings.HasPrefix(lines[0], "```") && strings.TrimSpace(lines[len(lines)-1]) == "```" {
+		lines = lines[1 : len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// splitParagraphs splits text at blank lines, trimming trailing spaces.
+func splitParagraphs(text string) [][]string {
+	var paragraphs [][]string
+	var current []string
+	for _, l := range strings.Split(text, "\n") {
+		l = strings.TrimRight(l, " \t")
+		if l == "" {
+			if len(current) > 0 {
+				paragraphs = append(paragraphs, current)
+				current = nil
+			}
+			continue
+		}
+		current = append(current, l)
+	}
+	if len(current) > 0 {
+		paragraphs = append(paragraphs, current)
+	}
+	return paragraphs
+}
+
+// isTrailerBlock reports whether every line of the paragraph is a trailer.
+func isTrailerBlock(lines []string) bool {
+	for _, l := range lines {
+		if !trailerLine.MatchString(l) {
+			return false
+		}
+	}
+	return true
+}
+
+// wrapParagraph reflows a paragraph at bodyWidth. List items are wrapped
+// individually with a hanging indent; indented lines such as code are kept.
+func wrapParagraph(lines []string) string {
+	var out []string
+	var words []string
+	indent := ""
+	flush := func() {
+		if len(words) > 0 {
+			out = append(out, wrapWords(words, indent)...)
+		}
+		words, indent = nil, ""
+	}
+
+	for _, l := range lines {
+		switch {
+		case listItem.MatchString(l):
+			flush()
+			marker := listItem.FindString(l)
+			indent = strings.Repeat(" ", len(marker))
+			words = append([]string{strings.TrimSpace(marker)}, strings.Fields(l[len(marker):])...)
+		case strings.HasPrefix(l, " ") || strings.HasPrefix(l, "\t"):
+			if indent != "" && strings.HasPrefix(l, indent) {
+				// Continuation of a list item.
+				words = append(words, strings.Fields(l)...)
+				continue
+			}
+			flush()
+			out = append(out, l)
+		default:
+			if indent != "" {
+				flush()
+			}
+			words = append(words, strings.Fields(l)...)
+		}
+	}
+	flush()
+	return strings.Join(out, "\n")
+}
+
+// wrapWords joins words into lines of at most bodyWidth columns. Lines after
+// the first are prefixed with indent. Words longer than a line, such as
+// URLs, are never split.
+func wrapWords(words []string, indent string) []string {
+	var lines []string
+	line := words[0]
+	for _, w := range words[1:] {
+		if len(line)+1+len(w) > bodyWidth {
+			lines = append(lines, line)
+			line = indent + w
+			continue
+		}
+		line += " " + w
+	}
+	return append(lines, line)
+}
@@ -0,0 +1,189 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/doganarif/giq/internal/ai"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// prTemplates are the places a pull request template is looked up, relative
+// to the root of the working tree, as on GitHub.
+var prTemplates = []string{
+	".github/pull_request_template.md",
+	".github/PULL_REQUEST_TEMPLATE.md",
+	"pull_request_template.md",
+	"PULL_REQUEST_TEMPLATE.md",
+	"docs/pull_request_template.md",
+	"docs/PULL_REQUEST_TEMPLATE.md",
+}
+
+// defaultBases are the branches tried as the target of a pull request when
+// origin/HEAD is not set.
+var defaultBases = []string{"main", "master", "origin/main", "origin/master"}
+
+// BranchChanges are the changes of the current branch since it diverged
+// from a target branch.
+type BranchChanges struct {
+	Base      string
+	MergeBase *object.Commit
+	// Commits are the commits on the branch, oldest first, without merges.
+	Commits []*object.Commit
+	Diff    string
+	Files   string
+}
+
+// PullRequest is a generated pull request title and Markdown description.
+type PullRequest struct {
+	Title string
+	Body  string
+}
+
+// String returns the title on the first line, followed by a blank line and
+// the description.
+func (p PullRequest) String() string {
+	return p.Title + "\n\n" + p.Body + "\n"
+}
+
+// DefaultBase returns the branch pull requests target by default: the one
+// origin/HEAD points to, or the first of main and master that exists.
+func (a *App) DefaultBase() (string, error) {
+	if ref, err := a.Repo.Reference(plumbing.NewRemoteHEADReferenceName("origin"), false); err == nil && ref.Type() == plumbing.SymbolicReference {
+		return ref.Target().Short(), nil
+	}
+	for _, base := range defaultBases {
+		if _, err := a.Repo.ResolveRevision(plumbing.Revision(base)); err == nil {
+			return base, nil
+		}
+	}
+	return "", fmt.Errorf("no main or master branch found, choose the target branch with --base")
+}
+
+// BranchChanges returns the commits and the cumulative diff of HEAD since
+// its merge base with base.
+func (a *App) BranchChanges(base string) (*BranchChanges, error) {
+	if a.Repo == nil {
+		return nil, fmt.Errorf("not a git repository")
+	}
+	head, err := a.Repo.Head()
+	if err != nil {
+		return nil, err
+	}
+	headCommit, err := a.Repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, err
+	}
+	hash, err := a.Repo.ResolveRevision(plumbing.Revision(base))
+	if err != nil {
+		return nil, fmt.Errorf("resolving %s: %w", base, err)
+	}
+	baseCommit, err := a.Repo.CommitObject(*hash)
+	if err != nil {
+		return nil, err
+	}
+
+	bases, err := headCommit.MergeBase(baseCommit)
+	if err != nil {
+		return nil, err
+	}
+	if len(bases) == 0 {
+		return nil, fmt.Errorf("HEAD and %s have no common history", base)
+	}
+	mergeBase := bases[0]
+
+	// git lists the branch's commits in one walk, oldest first.
+	out, err := a.gitOutput(nil, "rev-list", "--no-merges", "--reverse", mergeBase.Hash.String()+"..HEAD")
+	if err != nil {
+		return nil, err
+	}
+	var commits []*object.Commit
+	for _, line := range strings.Fields(out) {
+		c, err := a.Repo.CommitObject(plumbing.NewHash(line))
+		if err != nil {
+			return nil, err
+		}
+		commits = append(commits, c)
+	}
+
+	from, err := mergeBase.Tree()
+	if err != nil {
+		return nil, err
+	}
+	to, err := headCommit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	diff, files, err := treeDiff(from, to)
+	if err != nil {
+		return nil, err
+	}
+	return &BranchChanges{Base: base, MergeBase: mergeBase, Commits: commits, Diff: diff, Files: files}, nil
+}
+
+// PRTemplate returns the repository's pull request template, or "" if it
+// has none.
+func (a *App) PRTemplate() string {
+	root := a.RepoRoot()
+	if root == "" {
+		return ""
+	}
+	for _, name := range prTemplates {
+		if data, err := os.ReadFile(filepath.Join(root, name)); err == nil {
+			return strings.TrimSpace(string(data))
+		}
+	}
+	return ""
+}
+
+// PRPrompt builds the prompt asking for a pull request title and description
+// for the branch's changes, with diff being their prepared diff.
+func (a *App) PRPrompt(changes *BranchChanges, diff string) string {
+	var b strings.Builder
+	b.WriteString("Write the title and description of a pull request for the following changes. " +
+		"Start with a concise title of at most 72 characters on the first line, followed by a blank line and the description in Markdown. ")
+	if a.Conventional() {
+		b.WriteString("Write the title in the Conventional Commits format. ")
+	}
+	if template := a.PRTemplate(); template != "" {
+		b.WriteString("Fill in the repository's pull request template below, keeping its headings and checklists; " +
+			"leave checkboxes you cannot verify from the changes unchecked, and drop HTML comments.\n\nTemplate:\n")
+		b.WriteString(template + "\n\n")
+	} else {
+		b.WriteString("Use these sections: \"## Summary\" with what the change does and why, \"## Changes\" with a bullet list of " +
+			"the notable changes, \"## Testing\" with how the change was or should be tested, and \"## Risk\" with what could break " +
+			"and how to roll back.\n\n")
+	}
+	b.WriteString("Do not invent ticket numbers, links or test results that are not in the changes. Do not wrap the answer in a code block.\n\n")
+
+	if branch := a.currentBranch(); branch != "" {
+		fmt.Fprintf(&b, "Branch: %s (into %s)\n\n", branch, changes.Base)
+	}
+	b.WriteString("Commits:\n")
+	for _, c := range changes.Commits {
+		subject, _ := SplitMessage(c.Message)
+		fmt.Fprintf(&b, "- %s\n", subject)
+	}
+	b.WriteString("\nDiff:\n")
+	b.WriteString(diff)
+	return b.String()
+}
+
+// GeneratePullRequest asks the model for a pull request from prompt.
+func (a *App) GeneratePullRequest(prompt string) (PullRequest, error) {
+	answer, err := ai.GenerateText(a.Config, prompt, 1024)
+	if err != nil {
+		return PullRequest{}, err
+	}
+	title, body := SplitMessage(stripCodeFence(answer))
+	// Models like to format the title as a heading.
+	title = strings.TrimSpace(strings.TrimLeft(title, "#"))
+	title = strings.TrimPrefix(title, "Title: ")
+	if title == "" {
+		return PullRequest{}, fmt.Errorf("the model did not return a pull request")
+	}
+	return PullRequest{Title: title, Body: body}, nil
+}
@@ -0,0 +1,146 @@
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// redactedPlaceholder replaces every redacted value in the diff.
+const redactedPlaceholder = "[REDACTED]"
+
+// defaultRedactPatterns mask common credential formats even when no
+// redact_patterns are configured.
+var defaultRedactPatterns = []string{
+	// AWS access key IDs.
+	`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`,
+	// GitHub tokens.
+	`\bgh[pousr]_[A-Za-z0-9]{36,}\b`,
+	`\bgithub_pat_[A-Za-z0-9_]{22,}\b`,
+	// Slack tokens.
+	`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`,
+	// OpenAI and Anthropic API keys.
+	`\bsk-(?:ant-)?[A-Za-z0-9_-]{20,}\b`,
+	// Google API keys.
+	`\bAIza[0-9A-Za-z_-]{35}\b`,
+	// Quoted literals assigned to secret-looking names. Identifiers such as
+	// cfg.APIKey are code, not secrets, and are left alone.
+	`(?i)(?:password|passwd|secret|token|api[_-]?key|access[_-]?key|private[_-]?key)["']?\s*(?::=|[:=])\s*["']([^"'\s]{8,})["']`,
+}
+
+// privacyRules holds the compiled exclude and redaction settings.
+type privacyRules struct {
+	exclude  []*regexp.Regexp
+	patterns []*regexp.Regexp
+}
+
+// privacyRules compiles the configured exclude globs and redaction patterns.
+func (a *App) privacyRules() (*privacyRules, error) {
+	rules := &privacyRules{}
+	for _, glob := range a.Config.ExcludePaths {
+		re, err := globToRegexp(glob)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclude_paths pattern %q: %w", glob, err)
+		}
+		rules.exclude = append(rules.exclude, re)
+	}
+	for _, pattern := range append(defaultRedactPatterns, a.Config.RedactPatterns...) {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact_patterns pattern %q: %w", pattern, err)
+		}
+		rules.patterns = append(rules.patterns, re)
+	}
+	return rules, nil
+}
+
+// excluded reports whether the file at path must not be sent to the AI.
+func (r *privacyRules) excluded(path string) bool {
+	for _, re := range r.exclude {
+		if re.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactLine masks every match of the redaction patterns in line and returns
+// the number of values masked. For patterns with a capture group only the
+// first group is masked, so the surrounding key name stays readable.
+func (r *privacyRules) redactLine(line string) (string, int) {
+	count := 0
+	for _, re := range r.patterns {
+		matches := re.FindAllStringSubmatchIndex(line, -1)
+		if matches == nil {
+			continue
+		}
+		var b strings.Builder
+		last := 0
+		for _, m := range matches {
+			start, end := m[0], m[1]
+			if len(m) >= 4 && m[2] >= 0 {
+				start, end = m[2], m[3]
+			}
+			if start < last {
+				continue
+			}
+			b.WriteString(line[last:start])
+			b.WriteString(redactedPlaceholder)
+			last = end
+			count++
+		}
+		b.WriteString(line[last:])
+		line = b.String()
+	}
+	return line, count
+}
+
+// redact masks secrets in the hunks of f and returns the number of values masked.
+func (r *privacyRules) redact(f *fileDiff) int {
+	count := 0
+	for _, h := range f.hunks {
+		for i, l := range h.lines {
+			var n int
+			h.lines[i], n = r.redactLine(l)
+			count += n
+		}
+	}
+	return count
+}
+
+// globToRegexp converts a gitignore-like glob into a regular expression.
+// "**" matches across directories, "*" and "?" stay within one path segment,
+// and a pattern without a slash matches the file name in any directory.
+func globToRegexp(glob string) (*regexp.Regexp, error) {
+	glob = strings.TrimPrefix(glob, "/")
+	var b strings.Builder
+	if !strings.Contains(glob, "/") {
+		b.WriteString(`^(?:.*/)?`)
+	} else {
+		b.WriteString(`^`)
+	}
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+				if i+1 < len(glob) && glob[i+1] == '/' {
+					// "**/" also matches zero directories.
+					b.WriteString(`(?:.*/)?`)
+					i++
+				} else {
+					b.WriteString(`.*`)
+				}
+			} else {
+				b.WriteString(`[^/]*`)
+			}
+		case '?':
+			b.WriteString(`[^/]`)
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	// A pattern naming a directory also covers everything below it.
+	b.WriteString(`(?:/.*)?$`)
+	return regexp.Compile(b.String())
+}
@@ -0,0 +1,35 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/doganarif/giq/internal/config"
+)
+
+func TestRedactLine(t *testing.T) {
+	a := &App{Config: &config.Config{}}
+	rules, err := a.privacyRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		line string
+		want string
+		n    int
+	}{
+		{`password = "hunter2hunter2"`, `password = "[REDACTED]"`, 1},
+		{`secret := "Xk9$mQ2#vL8pR4tZ"`, `secret := "[REDACTED]"`, 1},
+		{`api_key: 'abcd1234efgh'`, `api_key: '[REDACTED]'`, 1},
+		{`key := "sk-abcdefghijklmnopqrstuvwx"`, `key := "[REDACTED]"`, 1},
+		// Identifiers are code, not secrets.
+		{`apiKey:  cfg.AnthropicAPIKey,`, `apiKey:  cfg.AnthropicAPIKey,`, 0},
+		{`password = getPassword(cfg)`, `password = getPassword(cfg)`, 0},
+		{`token := strings.TrimSpace(raw)`, `token := strings.TrimSpace(raw)`, 0},
+	}
+	for _, tt := range tests {
+		got, n := rules.redactLine(tt.line)
+		if got != tt.want || n != tt.n {
+			t.Errorf("redactLine(%q) = %q, %d; want %q, %d", tt.line, got, n, tt.want, tt.n)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// semverTag matches a semantic version tag such as v1.2.3 or 1.2.3-rc.1.
+var semverTag = regexp.MustCompile(`^(v?)(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(?:-([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?(?:\+[0-9A-Za-z.-]+)?$`)
+
+// prereleaseLabel matches a pre-release label giq can number, such as rc.
+var prereleaseLabel = regexp.MustCompile(`^[0-9A-Za-z-]+$`)
+
+// Version is a semantic version, as found in a tag.
+type Version struct {
+	// Prefix is "v" if the tag has one.
+	Prefix              string
+	Major, Minor, Patch int
+	// Pre is the pre-release suffix without its dash, such as "rc.1".
+	Pre string
+	// Tag is the name of the tag the version was read from.
+	Tag string
+}
+
+// ParseVersion parses a semantic version tag. Build metadata is ignored.
+func ParseVersion(tag string) (Version, bool) {
+	m := semverTag.FindStringSubmatch(tag)
+	if m == nil {
+		return Version{}, false
+	}
+	v := Version{Prefix: m[1], Pre: m[5], Tag: tag}
+	v.Major, _ = strconv.Atoi(m[2])
+	v.Minor, _ = strconv.Atoi(m[3])
+	v.Patch, _ = strconv.Atoi(m[4])
+	return v, true
+}
+
+// String returns the tag name of the version.
+func (v Version) String() string {
+	s := fmt.Sprintf("%s%d.%d.%d", v.Prefix, v.Major, v.Minor, v.Patch)
+	if v.Pre != "" {
+		s += "-" + v.Pre
+	}
+	return s
+}
+
+// Compare returns -1, 0 or 1 as v is lower than, equal to or higher than w,
+// following semver precedence.
+func (v Version) Compare(w Version) int {
+	for _, d := range []int{v.Major - w.Major, v.Minor - w.Minor, v.Patch - w.Patch} {
+		if d != 0 {
+			return sign(d)
+		}
+	}
+	// A pre-release comes before its release.
+	switch {
+	case v.Pre == w.Pre:
+		return 0
+	case v.Pre == "":
+		return 1
+	case w.Pre == "":
+		return -1
+	}
+	a, b := strings.Split(v.Pre, "."), strings.Split(w.Pre, ".")
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := comparePreField(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return sign(len(a) - len(b))
+}
+
+// comparePreField compares pre-release identifiers: numbers numerically and
+// before words, words in ASCII order.
+func comparePreField(a, b string) int {
+	x, errA := strconv.Atoi(a)
+	y, errB := strconv.Atoi(b)
+	switch {
+	case errA == nil && errB == nil:
+		return sign(x - y)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+// core returns the version without its pre-release suffix.
+func (v Version) core() Version {
+	return Version{Prefix: v.Prefix, Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+}
+
+// Bump returns the next version for a major, minor or patch release.
+func (v Version) Bump(kind string) Version {
+	next := v.core()
+	switch kind {
+	case "major":
+		next.Major, next.Minor, next.Patch = v.Major+1, 0, 0
+	case "minor":
+		next.Minor, next.Patch = v.Minor+1, 0
+	default:
+		next.Patch = v.Patch + 1
+	}
+	return next
+}
+
+// ReleaseTags returns the semantic version tags that point at HEAD or one of
+// its ancestors.
+func (a *App) ReleaseTags() ([]Version, error) {
+	if a.Repo == nil {
+		return nil, fmt.Errorf("not a git repository")
+	}
+	if _, err := a.Repo.Head(); err != nil {
+		return nil, err
+	}
+	// git finds the tags reachable from HEAD in a single walk. Tags of trees
+	// or blobs are never merged, so they are left out too.
+	out, err := a.gitOutput(nil, "tag", "--merged", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	var versions []Version
+	for _, name := range strings.Fields(out) {
+		if v, ok := ParseVersion(name); ok {
+			versions = append(versions, v)
+		}
+	}
+	return versions, nil
+}
+
+// ReleasePlan is the proposed next release.
+type ReleasePlan struct {
+	// Latest is the highest release or pre-release tag before HEAD; Stable is
+	// the highest release without a pre-release suffix, 0.0.0 if there is none.
+	Latest, Stable Version
+	// Bump is major, minor or patch.
+	Bump string
+	Next Version
+	// Reasons justify the bump.
+	Reasons []string
+	// Changes are the commits listed in the tag message.
+	Changes Changelog
+}
+
+// LatestVersions returns the highest of versions and the highest without a
+// pre-release suffix. A version without Tag means there is none.
+func LatestVersions(versions []Version) (latest, stable Version) {
+	for _, v := range versions {
+		if latest.Tag == "" || v.Compare(latest) > 0 {
+			latest = v
+		}
+		if v.Pre == "" && (stable.Tag == "" || v.Compare(stable) > 0) {
+			stable = v
+		}
+	}
+	return latest, stable
+}
+
+// ProposeRelease works out the next version after the latest and stable
+// releases from entries, the classified commits since the stable release.
+// unreleased holds the commits since the latest release or pre-release. pre,
+// if set, is a pre-release label such as "rc".
+func ProposeRelease(latest, stable Version, entries []ChangeEntry, unreleased map[plumbing.Hash]bool, pre string) (ReleasePlan, error) {
+	if pre != "" && !prereleaseLabel.MatchString(pre) {
+		return ReleasePlan{}, fmt.Errorf("invalid pre-release label %q", pre)
+	}
+	plan := ReleasePlan{Latest: latest, Stable: stable}
+	hasLatest := latest.Tag != ""
+	if stable.Tag == "" {
+		plan.Stable = Version{Prefix: "v"}
+		if hasLatest {
+			plan.Stable.Prefix = latest.Prefix
+		}
+	}
+
+	counts := make(map[string]int)
+	for _, e := range entries {
+		counts[e.Section]++
+	}
+	switch {
+	case counts[SectionBreaking] > 0 && plan.Stable.Major == 0:
+		// Before 1.0.0 anything may change; breaking changes bump the minor version.
+		plan.Bump = "minor"
+		plan.Reasons = append(plan.Reasons, fmt.Sprintf("%d breaking change(s), released as a minor version before 1.0.0", counts[SectionBreaking]))
+	case counts[SectionBreaking] > 0:
+		plan.Bump = "major"
+		plan.Reasons = append(plan.Reasons, fmt.Sprintf("%d breaking change(s)", counts[SectionBreaking]))
+	case counts[SectionFeatures] > 0:
+		plan.Bump = "minor"
+		plan.Reasons = append(plan.Reasons, fmt.Sprintf("%d new feature(s) and no breaking changes", counts[SectionFeatures]))
+	default:
+		plan.Bump = "patch"
+		plan.Reasons = append(plan.Reasons, fmt.Sprintf("%d fix(es) and %d other change(s), no features or breaking changes", counts[SectionFixes], counts[SectionOther]))
+	}
+	for _, e := range entries {
+		if e.Section == SectionBreaking {
+			plan.Reasons = append(plan.Reasons, "breaking: "+e.Note)
+		}
+	}
+
+	plan.Next = plan.Stable.Bump(plan.Bump)
+	// A pre-release tagged since may already be past the bump.
+	if hasLatest && plan.Latest.Pre != "" && plan.Latest.core().Compare(plan.Next) > 0 {
+		plan.Next = plan.Latest.core()
+	}
+	if pre != "" {
+		n := 1
+		if hasLatest && plan.Latest.core().Compare(plan.Next) == 0 {
+			if label, num, ok := strings.Cut(plan.Latest.Pre, "."); ok && label == pre {
+				if i, err := strconv.Atoi(num); err == nil {
+					n = i + 1
+				}
+			}
+		}
+		plan.Next.Pre = fmt.Sprintf("%s.%d", pre, n)
+	}
+
+	plan.Changes.Version = plan.Next.String()
+	// A release lists everything since the last one, a pre-release only
+	// what is new since the previous pre-release.
+	for _, e := range entries {
+		if pre == "" || unreleased[e.Hash] {
+			plan.Changes.Entries = append(plan.Changes.Entries, e)
+		}
+	}
+	return plan, nil
+}
+
+// TagMessage returns the message of the annotated tag of the release.
+func (p ReleasePlan) TagMessage() string {
+	return fmt.Sprintf("Release %s\n\n%s", p.Next, strings.TrimSpace(p.Changes.sections()))
+}
+
+// CreateTag creates the annotated tag of the release at HEAD. It runs git,
+// so tag.gpgSign and other tag settings are respected.
+func (a *App) CreateTag(name, message string) error {
+	if _, err := a.Repo.Tag(name); err == nil {
+		return fmt.Errorf("tag %s already exists", name)
+	}
+	// Keep the Markdown headings, which the default cleanup takes for comments.
+	return a.ExecGit("tag", "-a", "--cleanup=whitespace", "-m", message, name)
+}
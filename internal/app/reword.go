@@ -0,0 +1,197 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// CommitChanges resolves rev and returns the commit, its diff against its
+// first parent and the names of the changed files, one per line.
+func (a *App) CommitChanges(rev string) (*object.Commit, string, string, error) {
+	if a.Repo == nil {
+		return nil, "", "", fmt.Errorf("not a git repository")
+	}
+
+	hash, err := a.Repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, "", "", fmt.Errorf("resolving %s: %w", rev, err)
+	}
+	commit, err := a.Repo.CommitObject(*hash)
+	if err != nil {
+		return nil, "", "", err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	// A root commit is compared against the empty tree.
+	var parentTree *object.Tree
+	if commit.NumParents() > 0 {
+		parent, err := commit.Parent(0)
+		if err != nil {
+			return nil, "", "", err
+		}
+		if parentTree, err = parent.Tree(); err != nil {
+			return nil, "", "", err
+		}
+	}
+
+	diff, files, err := treeDiff(parentTree, tree)
+	if err != nil {
+		return nil, "", "", err
+	}
+	return commit, diff, files, nil
+}
+
+// treeDiff returns the diff between two trees and the names of the changed
+// files, one per line. A nil tree is empty.
+func treeDiff(from, to *object.Tree) (string, string, error) {
+	changes, err := object.DiffTree(from, to)
+	if err != nil {
+		return "", "", err
+	}
+	patch, err := changes.Patch()
+	if err != nil {
+		return "", "", err
+	}
+
+	var files strings.Builder
+	for _, c := range changes {
+		name := c.To.Name
+		if name == "" {
+			name = c.From.Name
+		}
+		files.WriteString(name + "\n")
+	}
+	return patch.String(), files.String(), nil
+}
+
+// Reword replaces the message of the commit with the given hash. HEAD is
+// amended; older commits are rewritten with a scripted interactive rebase,
+// which is aborted if anything goes wrong so the branch is left as it was.
+func (a *App) Reword(hash, msg string) error {
+	head, err := a.Repo.Head()
+	if err != nil {
+		return err
+	}
+	if head.Hash().String() == hash {
+		// --only leaves anything staged out of the amended commit.
+		return a.ExecGit("commit", "--amend", "--only", "--allow-empty", "-m", msg)
+	}
+
+	if err := a.checkRewordable(hash); err != nil {
+		return err
+	}
+
+	// The commits from the target up to HEAD, oldest first.
+	revList := []string{"rev-list", "--reverse", "HEAD"}
+	root := !a.hasCommit(hash + "^")
+	if !root {
+		revList = append(revList, "--not", hash+"^@")
+	}
+	out, err := a.gitOutput(nil, revList...)
+	if err != nil {
+		return err
+	}
+	var todo strings.Builder
+	for _, c := range strings.Fields(out) {
+		action := "pick"
+		if c == hash {
+			action = "reword"
+		}
+		fmt.Fprintf(&todo, "%s %s\n", action, c)
+	}
+
+	todoFile, err := writeTempFile("giq-rebase-todo-*", todo.String())
+	if err != nil {
+		return err
+	}
+	defer os.Remove(todoFile)
+	msgFile, err := writeTempFile("giq-COMMIT_EDITMSG-*", msg+"\n")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(msgFile)
+
+	// The message is final, so keep lines starting with "#" such as issue numbers.
+	args := []string{"-c", "commit.cleanup=whitespace", "rebase", "--interactive", "--no-autosquash"}
+	if root {
+		args = append(args, "--root")
+	} else {
+		args = append(args, hash+"^")
+	}
+	// git runs both editors with the file to edit as argument, so copying
+	// over it replaces the todo list and the message of the reworded commit.
+	cmd := exec.Command(a.GitCmd, args...)
+	cmd.Env = append(os.Environ(),
+		"GIT_SEQUENCE_EDITOR=cp "+shellQuote(todoFile),
+		"GIT_EDITOR=cp "+shellQuote(msgFile),
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if abortErr := exec.Command(a.GitCmd, "rebase", "--abort").Run(); abortErr != nil {
+			return fmt.Errorf("rewording failed: %s\nthe rebase could not be aborted, run \"git rebase --abort\": %w", strings.TrimSpace(string(out)), abortErr)
+		}
+		return fmt.Errorf("rewording failed, the rebase was aborted: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// checkRewordable returns an error if the commit cannot safely be reworded
+// with a rebase.
+func (a *App) checkRewordable(hash string) error {
+	if err := exec.Command(a.GitCmd, "merge-base", "--is-ancestor", hash, "HEAD").Run(); err != nil {
+		return fmt.Errorf("%s is not an ancestor of HEAD", hash[:7])
+	}
+
+	// Aborting on failure must never touch a rebase the user started.
+	for _, state := range []string{"rebase-merge", "rebase-apply"} {
+		path, err := a.gitPath(state)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(path); err == nil {
+			return fmt.Errorf("a rebase is already in progress")
+		}
+	}
+
+	status, err := a.gitOutput(nil, "status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(status) != "" {
+		return fmt.Errorf("you have uncommitted changes, commit or stash them first")
+	}
+
+	merges := []string{"rev-list", "--merges", "HEAD"}
+	if a.hasCommit(hash + "^") {
+		merges = append(merges, "--not", hash+"^@")
+	}
+	out, err := a.gitOutput(nil, merges...)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(out) != "" {
+		return fmt.Errorf("cannot reword %s: merge commits follow it on this branch", hash[:7])
+	}
+	return nil
+}
+
+// writeTempFile writes content to a new temporary file and returns its path.
+func writeTempFile(pattern, content string) (string, error) {
+	f, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := f.WriteString(content); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
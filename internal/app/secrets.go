@@ -0,0 +1,235 @@
+package app
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SecretsAllowlistFile is the name of the allowlist for the secret scanner,
+// looked up in the root of the working tree. Each non-empty line that is not
+// a comment is either "path:<glob>" to skip files, or a regular expression
+// matched against the detected value.
+const SecretsAllowlistFile = ".giq-secrets-allowlist"
+
+// SecretAllowMarker, anywhere on an added line, suppresses findings for that line.
+const SecretAllowMarker = "giq:allow"
+
+// secretRule is a high-confidence pattern for a credential. If the pattern
+// has a capture group, the group is the secret value and is masked when
+// reported; otherwise the match is only a marker and is shown as is.
+type secretRule struct {
+	name    string
+	pattern *regexp.Regexp
+	// minEntropy, if set, discards matches whose value looks like a
+	// placeholder rather than a random secret.
+	minEntropy float64
+}
+
+var secretRules = []secretRule{
+	{name: "AWS access key ID", pattern: regexp.MustCompile(`\b((?:AKIA|ASIA)[0-9A-Z]{16})\b`)},
+	{name: "AWS secret access key", pattern: regexp.MustCompile(`(?i)aws.{0,20}secret.{0,20}[:=]\s*["']?([A-Za-z0-9/+]{40})\b`)},
+	{name: "GitHub token", pattern: regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,})\b`)},
+	{name: "GitHub fine-grained token", pattern: regexp.MustCompile(`\b(github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{name: "Slack token", pattern: regexp.MustCompile(`\b(xox[abposr]-[A-Za-z0-9-]{10,})\b`)},
+	{name: "Stripe live key", pattern: regexp.MustCompile(`\b((?:sk|rk)_live_[A-Za-z0-9]{20,})\b`)},
+	{name: "Google API key", pattern: regexp.MustCompile(`\b(AIza[0-9A-Za-z_-]{35})\b`)},
+	// Anthropic keys also match the OpenAI pattern, so they are checked first.
+	{name: "Anthropic API key", pattern: regexp.MustCompile(`\b(sk-ant-[A-Za-z0-9_-]{32,})\b`)},
+	{name: "OpenAI API key", pattern: regexp.MustCompile(`\b(sk-(?:proj-)?[A-Za-z0-9_-]{32,})\b`), minEntropy: 3.5},
+	{name: "Private key", pattern: regexp.MustCompile(`-----BEGIN (?:[A-Z]+ )?PRIVATE KEY(?: BLOCK)?-----`)},
+	{
+		name:       "High-entropy secret assignment",
+		pattern:    regexp.MustCompile(`(?i)[\w.-]*(?:secret|token|passw(?:or)?d|api[_-]?key|access[_-]?key|credential)[\w.-]*["']?\s*(?::=|[:=])\s*["']([^\s"']{16,})["']`),
+		minEntropy: 3.8,
+	},
+}
+
+// SecretFinding is a potential secret in an added line of the staged diff.
+type SecretFinding struct {
+	File string
+	Line int
+	Rule string
+	// Preview is the detected value with most characters masked.
+	Preview string
+}
+
+func (f SecretFinding) String() string {
+	return fmt.Sprintf("%s:%d: %s (%s)", f.File, f.Line, f.Rule, f.Preview)
+}
+
+// ScanSecrets looks for credentials in the lines added by diff. Findings
+// covered by the repository's allowlist or marked with "giq:allow" on the
+// same line are not reported.
+func (a *App) ScanSecrets(diff string) ([]SecretFinding, error) {
+	allow, err := a.loadSecretsAllowlist()
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []SecretFinding
+	for _, f := range parseDiff(diff) {
+		if f.binary || allow.skipsPath(f.path) {
+			continue
+		}
+		for _, h := range f.hunks {
+			line := hunkNewStart(h.header)
+			for _, l := range h.lines {
+				switch {
+				case strings.HasPrefix(l, "+"):
+					if !strings.Contains(l, SecretAllowMarker) {
+						for _, finding := range scanLine(l[1:]) {
+							if !allow.allowsValue(finding.value) {
+								findings = append(findings, SecretFinding{File: f.path, Line: line, Rule: finding.rule, Preview: finding.preview})
+							}
+						}
+					}
+					line++
+				case strings.HasPrefix(l, " "):
+					line++
+				}
+			}
+		}
+	}
+	return findings, nil
+}
+
+type lineFinding struct {
+	rule    string
+	value   string
+	preview string
+}
+
+// scanLine returns the secrets matched in a single line. A value matched by
+// several rules is reported once, under the first and most specific rule.
+func scanLine(line string) []lineFinding {
+	var found []lineFinding
+	seen := make(map[string]bool)
+	for _, rule := range secretRules {
+		for _, m := range rule.pattern.FindAllStringSubmatch(line, -1) {
+			value, preview := m[0], m[0]
+			if len(m) > 1 {
+				value = m[1]
+				preview = maskSecret(value)
+			}
+			if seen[value] || rule.minEntropy > 0 && shannonEntropy(value) < rule.minEntropy {
+				continue
+			}
+			seen[value] = true
+			found = append(found, lineFinding{rule: rule.name, value: value, preview: preview})
+		}
+	}
+	return found
+}
+
+// shannonEntropy returns the entropy of s in bits per character.
+func shannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+	n := float64(len([]rune(s)))
+	var entropy float64
+	for _, c := range counts {
+		p := float64(c) / n
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// maskSecret keeps only enough of a value to recognize it.
+func maskSecret(value string) string {
+	if len(value) <= 8 {
+		return strings.Repeat("*", len(value))
+	}
+	return value[:4] + strings.Repeat("*", len(value)-6) + value[len(value)-2:]
+}
+
+// hunkNewStart returns the first line number in the new file of a hunk
+// header such as "@@ -1,3 +4,5 @@".
+func hunkNewStart(header string) int {
+	fields := strings.Fields(header)
+	for _, f := range fields {
+		if strings.HasPrefix(f, "+") {
+			start, _, _ := strings.Cut(f[1:], ",")
+			if n, err := strconv.Atoi(start); err == nil {
+				return n
+			}
+		}
+	}
+	return 1
+}
+
+// secretsAllowlist holds the parsed allowlist file.
+type secretsAllowlist struct {
+	paths  []*regexp.Regexp
+	values []*regexp.Regexp
+}
+
+func (l *secretsAllowlist) skipsPath(path string) bool {
+	for _, re := range l.paths {
+		if re.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *secretsAllowlist) allowsValue(value string) bool {
+	for _, re := range l.values {
+		if re.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadSecretsAllowlist reads the allowlist from the root of the working tree.
+// A missing file yields an empty allowlist.
+func (a *App) loadSecretsAllowlist() (*secretsAllowlist, error) {
+	allow := &secretsAllowlist{}
+	root := a.RepoRoot()
+	if root == "" {
+		return allow, nil
+	}
+
+	path := filepath.Join(root, SecretsAllowlistFile)
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return allow, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		if glob, ok := strings.CutPrefix(entry, "path:"); ok {
+			re, err := globToRegexp(strings.TrimSpace(glob))
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", SecretsAllowlistFile, n, err)
+			}
+			allow.paths = append(allow.paths, re)
+			continue
+		}
+		re, err := regexp.Compile(entry)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", SecretsAllowlistFile, n, err)
+		}
+		allow.values = append(allow.values, re)
+	}
+	return allow, scanner.Err()
+}
@@ -0,0 +1,443 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/doganarif/giq/internal/ai"
+)
+
+// promptHunkLines is the number of lines of each hunk shown to the model;
+// the rest is summarized.
+const promptHunkLines = 80
+
+var (
+	hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@(.*)$`)
+	splitEntry = regexp.MustCompile(`(?i)^\W*(commit|hunks)(?:\s*\d+)?\W*:\s*(.*)$`)
+)
+
+// Hunk is a part of the staged changes that can be committed on its own: an
+// "@@" section of a text file, or the whole file for binary files and for
+// files that are added, deleted or change mode.
+type Hunk struct {
+	File    string
+	Header  string
+	Added   int
+	Deleted int
+
+	seq  int
+	file *fileDiff
+	hunk *hunk // nil for a whole file
+	// Line ranges from the hunk header.
+	oldStart, oldCount, newStart, newCount int
+}
+
+// String describes the hunk on one line.
+func (h *Hunk) String() string {
+	if h.hunk == nil {
+		return fmt.Sprintf("%s (whole file, +%d -%d)", h.File, h.Added, h.Deleted)
+	}
+	return fmt.Sprintf("%s %s (+%d -%d)", h.File, h.Header, h.Added, h.Deleted)
+}
+
+// SplitGroup is a proposed commit: its message and the hunks it records.
+type SplitGroup struct {
+	Message string
+	Hunks   []*Hunk
+}
+
+// StagedHunks returns the staged changes as hunks, in diff order.
+func (a *App) StagedHunks() ([]*Hunk, error) {
+	if a.Repo == nil {
+		return nil, fmt.Errorf("not a git repository")
+	}
+	return a.diffHunks("--cached")
+}
+
+// diffHunks runs "git diff" with args and returns its hunks.
+func (a *App) diffHunks(args ...string) ([]*Hunk, error) {
+	// The patches must apply again, so ask for a plain diff with paths from
+	// the top of the repository, whatever the user's diff settings are.
+	// Renames are split into a deletion and an addition so each side can be
+	// handled on its own.
+	diff, err := a.gitOutput(nil, append([]string{"diff", "--binary", "--no-renames", "--no-color",
+		"--no-ext-diff", "--no-textconv", "--no-relative", "--src-prefix=a/", "--dst-prefix=b/"}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	return splitHunks(parseDiff(diff)), nil
+}
+
+// applyPatch applies patch to the index, or the index in env, from the top of
+// the repository, since git ignores paths outside the current directory.
+func (a *App) applyPatch(env []string, patch string, args ...string) error {
+	file, err := writeTempFile("giq-*.patch", patch)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file)
+
+	cmd := exec.Command(a.GitCmd, append(append([]string{"apply", "--cached", "--whitespace=nowarn"}, args...), file)...)
+	cmd.Dir = a.RepoRoot()
+	cmd.Env = append(os.Environ(), env...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git apply: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// splitHunks turns parsed file diffs into hunks.
+func splitHunks(files []*fileDiff) []*Hunk {
+	var hunks []*Hunk
+	for _, f := range files {
+		if f.binary || len(f.hunks) == 0 || wholeFileChange(f) {
+			hunks = append(hunks, &Hunk{File: f.path, Added: f.added, Deleted: f.deleted, seq: len(hunks), file: f})
+			continue
+		}
+		for _, fh := range f.hunks {
+			h := &Hunk{File: f.path, Header: fh.header, seq: len(hunks), file: f, hunk: fh}
+			m := hunkHeader.FindStringSubmatch(fh.header)
+			h.oldStart, h.oldCount = rangeStart(m[1]), rangeCount(m[2])
+			h.newStart, h.newCount = rangeStart(m[3]), rangeCount(m[4])
+			for _, l := range fh.lines {
+				if strings.HasPrefix(l, "+") {
+					h.Added++
+				} else if strings.HasPrefix(l, "-") {
+					h.Deleted++
+				}
+			}
+			hunks = append(hunks, h)
+		}
+	}
+	return hunks
+}
+
+// wholeFileChange reports whether the file's header changes more than its
+// content, such as its mode, or a hunk header cannot be read, so its hunks
+// cannot be committed separately.
+func wholeFileChange(f *fileDiff) bool {
+	for _, l := range f.header[1:] {
+		if !strings.HasPrefix(l, "index ") && !strings.HasPrefix(l, "--- ") && !strings.HasPrefix(l, "+++ ") {
+			return true
+		}
+	}
+	for _, h := range f.hunks {
+		if !hunkHeader.MatchString(h.header) {
+			return true
+		}
+	}
+	return false
+}
+
+// rangeStart parses the first line of a hunk range.
+func rangeStart(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// rangeCount parses the line count of a hunk range, which is 1 if omitted.
+func rangeCount(s string) int {
+	if s == "" {
+		return 1
+	}
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// ProposeSplit asks the model to group the hunks into logically separate
+// commits. Hunks the model leaves out are collected in a last group.
+func (a *App) ProposeSplit(hunks []*Hunk) ([]SplitGroup, error) {
+	prompt, err := a.SplitPrompt(hunks)
+	if err != nil {
+		return nil, err
+	}
+	// Each hunk may end up in a commit of its own, with a message and its number.
+	answer, err := ai.GenerateText(a.Config, prompt, max(512, 48*len(hunks)))
+	if err != nil {
+		return nil, err
+	}
+	groups := ParseSplit(answer, hunks)
+	if len(groups) == 0 {
+		return nil, fmt.Errorf("the model did not propose any commits")
+	}
+	if a.Conventional() {
+		for i, g := range groups {
+			scope := InferScope(groupFiles(g))
+			if g.Message == remainingMessage {
+				// Nothing tells what kind of changes the model left out.
+				groups[i].Message, _ = formatConventional("chore", scope, false, remainingMessage)
+				continue
+			}
+			if msg, ok := RepairConventional(g.Message, scope); ok {
+				groups[i].Message = msg
+			}
+		}
+	}
+	return groups, nil
+}
+
+// SplitPrompt builds the prompt asking the model to group the hunks. The
+// privacy rules apply to the hunks as they do to commit diffs.
+func (a *App) SplitPrompt(hunks []*Hunk) (string, error) {
+	rules, err := a.privacyRules()
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString("The following hunks are staged in a git repository. Group them into the smallest number of coherent, " +
+		"logically separate commits, so that each commit makes sense on its own and could be reviewed or reverted alone. " +
+		"Order the commits so each one builds on the previous ones, and give each a single line, concise, and descriptive commit message. ")
+	if a.Conventional() {
+		b.WriteString("Use the Conventional Commits format for the messages. ")
+	}
+	if style := a.commitStyle(); style != nil {
+		b.WriteString(style.prompt(rules))
+	}
+	b.WriteString("Every hunk must belong to exactly one commit. Answer only with the commits in this format, " +
+		"separated by blank lines:\nCommit: <commit message>\nHunks: <comma-separated hunk numbers>\n\n")
+
+	writeHunks(&b, hunks, rules)
+	return b.String(), nil
+}
+
+// writeHunks writes the numbered hunks for a prompt, applying the privacy
+// rules and shortening long hunks.
+func writeHunks(b *strings.Builder, hunks []*Hunk, rules *privacyRules) {
+	for i, h := range hunks {
+		fmt.Fprintf(b, "Hunk %d: %s\n", i+1, h.String())
+		switch {
+		case rules.excluded(h.File):
+			b.WriteString("(content excluded)\n")
+		case h.file.binary:
+			b.WriteString("(binary file)\n")
+		default:
+			var lines []string
+			if h.hunk != nil {
+				lines = h.hunk.lines
+			} else {
+				for _, fh := range h.file.hunks {
+					lines = append(lines, fh.lines...)
+				}
+			}
+			for j, l := range lines {
+				if j == promptHunkLines {
+					fmt.Fprintf(b, "(%d more lines)\n", len(lines)-j)
+					break
+				}
+				l, _ = rules.redactLine(l)
+				b.WriteString(l + "\n")
+			}
+		}
+		b.WriteByte('\n')
+	}
+}
+
+// remainingMessage is the message of the commit collecting the hunks the
+// model did not assign to any commit.
+const remainingMessage = "Update remaining changes"
+
+// ParseSplit reads the groups from the model's answer. Unknown and repeated
+// hunk numbers are ignored, and hunks that were left out are collected in a
+// last group so nothing staged is lost.
+func ParseSplit(answer string, hunks []*Hunk) []SplitGroup {
+	var groups []SplitGroup
+	assigned := make(map[int]bool)
+	message := ""
+	for _, line := range strings.Split(answer, "\n") {
+		m := splitEntry.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		value := strings.Trim(strings.TrimSpace(m[2]), "*`\"")
+		if strings.EqualFold(m[1], "commit") {
+			message = value
+			continue
+		}
+		if message == "" {
+			// Hunks without a message end up in the last group.
+			continue
+		}
+		g := SplitGroup{Message: message}
+		for _, field := range strings.FieldsFunc(value, func(r rune) bool { return r < '0' || r > '9' }) {
+			n, _ := strconv.Atoi(field)
+			if n >= 1 && n <= len(hunks) && !assigned[n] {
+				assigned[n] = true
+				g.Hunks = append(g.Hunks, hunks[n-1])
+			}
+		}
+		if len(g.Hunks) > 0 {
+			groups = append(groups, g)
+		}
+		message = ""
+	}
+
+	var rest SplitGroup
+	for i, h := range hunks {
+		if !assigned[i+1] {
+			rest.Hunks = append(rest.Hunks, h)
+		}
+	}
+	if len(rest.Hunks) > 0 {
+		rest.Message = remainingMessage
+		groups = append(groups, rest)
+	}
+	return groups
+}
+
+// groupFiles returns the files of the group's hunks, one per line.
+func groupFiles(g SplitGroup) string {
+	var b strings.Builder
+	seen := make(map[string]bool)
+	for _, h := range g.Hunks {
+		if !seen[h.File] {
+			seen[h.File] = true
+			b.WriteString(h.File + "\n")
+		}
+	}
+	return b.String()
+}
+
+// CommitSplit creates one commit per group on top of HEAD and returns their
+// hashes. Each group's tree is built on a temporary index first, and HEAD is
+// only moved once every patch has applied, so nothing changes on failure. The
+// groups must cover exactly the staged changes; the real index is never
+// written and matches the last commit afterwards. Like "git commit-tree",
+// this does not run commit hooks.
+func (a *App) CommitSplit(groups []SplitGroup) ([]string, error) {
+	staged, err := a.gitOutput(nil, "write-tree")
+	if err != nil {
+		return nil, err
+	}
+	parent := ""
+	if a.hasCommit("HEAD") {
+		out, err := a.gitOutput(nil, "rev-parse", "HEAD")
+		if err != nil {
+			return nil, err
+		}
+		parent = strings.TrimSpace(out)
+	}
+
+	index, env, err := tempIndex()
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(index)
+	if parent != "" {
+		_, err = a.gitOutput(env, "read-tree", parent)
+	} else {
+		_, err = a.gitOutput(env, "read-tree", "--empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make(map[*Hunk]bool)
+	trees := make([]string, len(groups))
+	for i, g := range groups {
+		if strings.TrimSpace(g.Message) == "" {
+			return nil, fmt.Errorf("commit %d has no message", i+1)
+		}
+		if err := a.applyPatch(env, buildPatch(selectAll(g.Hunks), applied, false)); err != nil {
+			return nil, fmt.Errorf("commit %d (%s) does not apply: %w", i+1, g.Message, err)
+		}
+		for _, h := range g.Hunks {
+			applied[h] = true
+		}
+		tree, err := a.gitOutput(env, "write-tree")
+		if err != nil {
+			return nil, err
+		}
+		trees[i] = strings.TrimSpace(tree)
+	}
+	if len(trees) == 0 || trees[len(trees)-1] != strings.TrimSpace(staged) {
+		return nil, fmt.Errorf("the commits do not add up to the staged changes")
+	}
+
+	var hashes []string
+	head := parent
+	for i, g := range groups {
+		args := []string{"commit-tree", trees[i], "-m", g.Message}
+		if head != "" {
+			args = append(args, "-p", head)
+		}
+		out, err := a.gitOutput(nil, args...)
+		if err != nil {
+			return nil, err
+		}
+		head = strings.TrimSpace(out)
+		hashes = append(hashes, head)
+	}
+	// The old value makes the update fail if HEAD moved in the meantime; an
+	// empty one requires the branch not to exist yet.
+	if _, err := a.gitOutput(nil, "update-ref", "-m", "giq split", "HEAD", head, parent); err != nil {
+		return nil, err
+	}
+	return hashes, nil
+}
+
+// buildPatch returns a patch of the selected hunks and lines. Unselected
+// lines are left out or turned into context, depending on which side the
+// patch applies to: the old side, or the new side (the index) for a patch
+// applied with --reverse. Hunk positions are shifted by the lines that the
+// applied hunks and the earlier hunks of the patch add or remove above them.
+func buildPatch(selections []HunkSelection, applied map[*Hunk]bool, reverse bool) string {
+	selections = append([]HunkSelection(nil), selections...)
+	sort.Slice(selections, func(i, j int) bool { return selections[i].Hunk.seq < selections[j].Hunk.seq })
+
+	var b, section strings.Builder
+	var file *fileDiff
+	delta := 0
+	flush := func() {
+		if section.Len() > 0 {
+			for _, l := range file.header {
+				b.WriteString(l + "\n")
+			}
+			b.WriteString(section.String())
+			section.Reset()
+		}
+	}
+	for _, s := range selections {
+		h := s.Hunk
+		if h.hunk == nil {
+			b.WriteString(h.file.String())
+			if h.file.binary {
+				// parseDiff drops the blank line ending a binary patch.
+				b.WriteByte('\n')
+			}
+			continue
+		}
+		if h.file != file {
+			flush()
+			file, delta = h.file, 0
+		}
+
+		lines, oldCount, newCount, changed := selectLines(h.hunk.lines, s.Lines, reverse)
+		if !changed {
+			continue
+		}
+		offset := 0
+		for o := range applied {
+			if o.file == h.file && o.seq < h.seq {
+				offset += o.newCount - o.oldCount
+			}
+		}
+		m := hunkHeader.FindStringSubmatch(h.Header)
+		oldStart, newStart := h.oldStart+offset, h.oldStart+offset+delta
+		if reverse {
+			oldStart, newStart = h.newStart+offset-delta, h.newStart+offset
+		}
+		fmt.Fprintf(&section, "@@ -%d,%d +%d,%d @@%s\n", oldStart, oldCount, newStart, newCount, m[5])
+		for _, l := range lines {
+			section.WriteString(l + "\n")
+		}
+		delta += newCount - oldCount
+	}
+	flush()
+	return b.String()
+}
@@ -0,0 +1,169 @@
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/doganarif/giq/internal/ai"
+	"github.com/go-git/go-git/v5"
+)
+
+var hintLine = regexp.MustCompile(`(?i)^\W*(?:hunk\s*)?(\d+)\W*\[([^\]]*)\]\s*(.*)$`)
+
+// HunkHint is the model's one-line description of a hunk and a label of the
+// group of related changes it belongs to.
+type HunkHint struct {
+	Group       string
+	Description string
+}
+
+// HunkSelection selects a hunk, or only some of its changed lines given as
+// indexes into its Lines.
+type HunkSelection struct {
+	Hunk  *Hunk
+	Lines map[int]bool // nil selects the whole hunk
+}
+
+// selectAll selects the given hunks as a whole.
+func selectAll(hunks []*Hunk) []HunkSelection {
+	selections := make([]HunkSelection, len(hunks))
+	for i, h := range hunks {
+		selections[i] = HunkSelection{Hunk: h}
+	}
+	return selections
+}
+
+// Lines returns the lines of a text hunk, or nil for a whole file.
+func (h *Hunk) Lines() []string {
+	if h.hunk == nil {
+		return nil
+	}
+	return h.hunk.lines
+}
+
+// UnstagedHunks returns the changes in the working tree that are not staged,
+// limited to paths if any are given.
+func (a *App) UnstagedHunks(paths []string) ([]*Hunk, error) {
+	if a.Repo == nil {
+		return nil, fmt.Errorf("not a git repository")
+	}
+	return a.diffHunks(append([]string{"--"}, paths...)...)
+}
+
+// IndexHunks returns the staged changes, limited to paths if any are given.
+func (a *App) IndexHunks(paths []string) ([]*Hunk, error) {
+	if a.Repo == nil {
+		return nil, fmt.Errorf("not a git repository")
+	}
+	return a.diffHunks(append([]string{"--cached", "--"}, paths...)...)
+}
+
+// UntrackedFiles returns the number of untracked files in the working tree,
+// which have no hunks to stage until they are added.
+func (a *App) UntrackedFiles() (int, error) {
+	w, err := a.Repo.Worktree()
+	if err != nil {
+		return 0, err
+	}
+	status, err := w.Status()
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, s := range status {
+		if s.Worktree == git.Untracked {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// Stage adds the selected unstaged changes to the index.
+func (a *App) Stage(selections []HunkSelection) error {
+	patch := buildPatch(selections, nil, false)
+	if patch == "" {
+		return nil
+	}
+	return a.applyPatch(nil, patch)
+}
+
+// Unstage removes the selected staged changes from the index, leaving the
+// working tree as it is.
+func (a *App) Unstage(selections []HunkSelection) error {
+	patch := buildPatch(selections, nil, true)
+	if patch == "" {
+		return nil
+	}
+	return a.applyPatch(nil, patch, "--reverse")
+}
+
+// selectLines returns the lines of a hunk that keeps only the selected
+// changes, its line counts, and whether any change is left.
+func selectLines(lines []string, selected map[int]bool, reverse bool) (out []string, oldCount, newCount int, changed bool) {
+	kept := false
+	for i, l := range lines {
+		sel := selected == nil || selected[i]
+		switch {
+		case strings.HasPrefix(l, `\`):
+			// "\ No newline at end of file" belongs to the line before it.
+			if kept {
+				out = append(out, l)
+			}
+			continue
+		case strings.HasPrefix(l, "+") && sel, strings.HasPrefix(l, "-") && sel:
+			out = append(out, l)
+			if l[0] == '+' {
+				newCount++
+			} else {
+				oldCount++
+			}
+			changed, kept = true, true
+		case strings.HasPrefix(l, "+") && !reverse, strings.HasPrefix(l, "-") && reverse:
+			// The line is on neither side of the patch.
+			kept = false
+		default:
+			// Context, or a change that stays as it is.
+			if l != "" {
+				l = l[1:]
+			}
+			out = append(out, " "+l)
+			oldCount++
+			newCount++
+			kept = true
+		}
+	}
+	return out, oldCount, newCount, changed
+}
+
+// DescribeHunks asks the model for a one-line description of each hunk and
+// a label grouping related hunks, in the order of hunks. Hunks the model
+// skips get an empty hint.
+func (a *App) DescribeHunks(hunks []*Hunk) ([]HunkHint, error) {
+	rules, err := a.privacyRules()
+	if err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	b.WriteString("The following hunks are changes in a git working tree. For each hunk, write a one-line description of what it changes, " +
+		"and a short label naming the logical change it belongs to; hunks that belong in the same commit share a label. " +
+		"Answer only with one line per hunk in this format:\n<hunk number>. [<label>] <description>\n\n")
+	writeHunks(&b, hunks, rules)
+
+	answer, err := ai.GenerateText(a.Config, b.String(), 32*len(hunks)+64)
+	if err != nil {
+		return nil, err
+	}
+	hints := make([]HunkHint, len(hunks))
+	for _, line := range strings.Split(answer, "\n") {
+		m := hintLine.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		if n, _ := strconv.Atoi(m[1]); n >= 1 && n <= len(hunks) {
+			hints[n-1] = HunkHint{Group: strings.TrimSpace(m[2]), Description: strings.TrimSpace(m[3])}
+		}
+	}
+	return hints, nil
+}
@@ -0,0 +1,206 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// styleCacheFile is the name of the style cache inside the git directory.
+const styleCacheFile = "giq-style.json"
+
+// styleCacheTTL is how long a derived style is reused before history is
+// sampled again.
+const styleCacheTTL = 24 * time.Hour
+
+var (
+	ticketPattern       = regexp.MustCompile(`\b[A-Z][A-Z0-9]+-\d+\b|#\d+\b`)
+	bracketPrefix       = regexp.MustCompile(`^\[[^\]]+\]`)
+	conventionalPattern = regexp.MustCompile(`^[a-z]+(?:\([^()]*\))?!?: `)
+)
+
+// commitStyle describes how commit messages are written in a repository.
+type commitStyle struct {
+	Samples  int       `json:"samples"`
+	Created  time.Time `json:"created"`
+	Examples []string  `json:"examples"`
+	Notes    []string  `json:"notes"`
+}
+
+// commitStyle returns the style of the repository's recent commits, from the
+// cache when it is fresh. It returns nil if sampling is disabled or there is
+// no usable history.
+func (a *App) commitStyle() *commitStyle {
+	samples := a.Config.StyleSamples
+	if samples <= 0 || a.Repo == nil {
+		return nil
+	}
+
+	cache := a.styleCachePath()
+	if cache != "" {
+		if style := readStyleCache(cache); style != nil && style.Samples == samples && time.Since(style.Created) < styleCacheTTL {
+			return style
+		}
+	}
+
+	subjects, err := a.recentSubjects(samples)
+	if err != nil || len(subjects) == 0 {
+		return nil
+	}
+	style := &commitStyle{
+		Samples:  samples,
+		Created:  time.Now(),
+		Examples: subjects,
+		Notes:    styleNotes(subjects),
+	}
+	if cache != "" {
+		if data, err := json.Marshal(style); err == nil {
+			_ = os.WriteFile(cache, data, 0644)
+		}
+	}
+	return style
+}
+
+// styleCachePath returns where the style of the repository is cached, or ""
+// if it cannot be determined.
+func (a *App) styleCachePath() string {
+	path, err := a.gitPath(styleCacheFile)
+	if err != nil {
+		return ""
+	}
+	return path
+}
+
+// readStyleCache returns the cached style at path, or nil.
+func readStyleCache(path string) *commitStyle {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var style commitStyle
+	if json.Unmarshal(data, &style) != nil {
+		return nil
+	}
+	return &style
+}
+
+// recentSubjects returns the subjects of up to n recent commits on HEAD.
+// Merges and commits generated by git (fixup!, squash!, reverts) say
+// nothing about how people write and are skipped.
+func (a *App) recentSubjects(n int) ([]string, error) {
+	iter, err := a.Repo.Log(&git.LogOptions{Order: git.LogOrderCommitterTime})
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	var subjects []string
+	err = iter.ForEach(func(c *object.Commit) error {
+		if len(subjects) >= n {
+			return storer.ErrStop
+		}
+		subject, _ := SplitMessage(c.Message)
+		if c.NumParents() > 1 || subject == "" || isGeneratedSubject(subject) {
+			return nil
+		}
+		subjects = append(subjects, subject)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return subjects, nil
+}
+
+// isGeneratedSubject reports whether git wrote the subject rather than a person.
+func isGeneratedSubject(subject string) bool {
+	return strings.HasPrefix(subject, "Merge ") || strings.HasPrefix(subject, "Revert \"") || isFixupSubject(subject)
+}
+
+// isFixupSubject reports whether the subject belongs to a commit meant to be
+// squashed into another.
+func isFixupSubject(subject string) bool {
+	for _, prefix := range []string{"fixup! ", "squash! ", "amend! "} {
+		if strings.HasPrefix(subject, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// styleNotes summarizes the conventions the subjects have in common.
+func styleNotes(subjects []string) []string {
+	var capitalized, period, tickets, brackets, conventional int
+	lengths := make([]int, 0, len(subjects))
+	for _, s := range subjects {
+		lengths = append(lengths, len(s))
+		// Casing is judged after any prefix such as "[ABC-1]" or "fix: ".
+		text := strings.TrimLeft(conventionalPattern.ReplaceAllString(bracketPrefix.ReplaceAllString(s, ""), ""), " ")
+		if r := []rune(text); len(r) > 0 && unicode.IsUpper(r[0]) {
+			capitalized++
+		}
+		if strings.HasSuffix(s, ".") {
+			period++
+		}
+		if ticketPattern.MatchString(s) {
+			tickets++
+		}
+		if bracketPrefix.MatchString(s) {
+			brackets++
+		}
+		if conventionalPattern.MatchString(s) {
+			conventional++
+		}
+	}
+	sort.Ints(lengths)
+
+	n := len(subjects)
+	most := func(count int) bool { return count*3 >= n*2 }
+	few := func(count int) bool { return count*3 <= n }
+
+	notes := []string{fmt.Sprintf("Subjects are typically about %d characters long.", lengths[n/2])}
+	if most(conventional) {
+		notes = append(notes, "Subjects use Conventional Commits prefixes such as \"fix(scope): \".")
+	}
+	if most(brackets) {
+		notes = append(notes, "Subjects start with a prefix in square brackets.")
+	}
+	if most(capitalized) {
+		notes = append(notes, "The text after any prefix starts with a capital letter.")
+	} else if few(capitalized) {
+		notes = append(notes, "The text after any prefix starts with a lower-case letter.")
+	}
+	if most(tickets) {
+		notes = append(notes, "Subjects reference a ticket or issue.")
+	}
+	if most(period) {
+		notes = append(notes, "Subjects end with a period.")
+	} else if few(period) {
+		notes = append(notes, "Subjects do not end with a period.")
+	}
+	return notes
+}
+
+// prompt returns the instructions describing the style for the model.
+func (s *commitStyle) prompt(rules *privacyRules) string {
+	var b strings.Builder
+	b.WriteString("Match the style of the repository's recent commit messages: casing, prefixes, ticket references, language and length. ")
+	b.WriteString(strings.Join(s.Notes, " "))
+	b.WriteString(" Recent subjects:\n")
+	for _, e := range s.Examples {
+		if rules != nil {
+			e, _ = rules.redactLine(e)
+		}
+		fmt.Fprintf(&b, "- %s\n", e)
+	}
+	return b.String()
+}
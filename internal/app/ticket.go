@@ -0,0 +1,85 @@
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// BranchTickets returns the ticket IDs found in the name of the current
+// branch with the configured ticket_pattern, in order of appearance. If the
+// pattern has a capture group, the group is the ID. It returns nothing if no
+// pattern is configured or HEAD is detached.
+func (a *App) BranchTickets() ([]string, error) {
+	if a.Config.TicketPattern == "" || a.Repo == nil {
+		return nil, nil
+	}
+	re, err := regexp.Compile(a.Config.TicketPattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ticket_pattern %q: %w", a.Config.TicketPattern, err)
+	}
+
+	var tickets []string
+	seen := make(map[string]bool)
+	for _, m := range re.FindAllStringSubmatch(a.currentBranch(), -1) {
+		ticket := m[0]
+		if len(m) > 1 && m[1] != "" {
+			ticket = m[1]
+		}
+		if !seen[ticket] {
+			seen[ticket] = true
+			tickets = append(tickets, ticket)
+		}
+	}
+	return tickets, nil
+}
+
+// AddTickets adds the ticket IDs of the current branch that msg does not
+// mention yet, following ticket_template: a template containing {subject},
+// such as "[{ticket}] {subject}", rewrites the subject line; any other, such
+// as "Refs: {ticket}", is added as a trailer.
+func (a *App) AddTickets(msg string) (string, error) {
+	tickets, err := a.BranchTickets()
+	if err != nil {
+		return "", err
+	}
+	var missing []string
+	for _, t := range tickets {
+		if !strings.Contains(msg, t) {
+			missing = append(missing, t)
+		}
+	}
+	if len(missing) == 0 || a.Config.TicketTemplate == "" {
+		return msg, nil
+	}
+	line := strings.ReplaceAll(a.Config.TicketTemplate, "{ticket}", strings.Join(missing, ", "))
+
+	subject, body := SplitMessage(msg)
+	if strings.Contains(line, "{subject}") {
+		subject = strings.ReplaceAll(line, "{subject}", subject)
+		if body == "" {
+			return subject, nil
+		}
+		return subject + "\n\n" + body, nil
+	}
+
+	// Join an existing trailer block, so git still recognizes all trailers.
+	paragraphs := splitParagraphs(body)
+	if len(paragraphs) > 0 && isTrailerBlock(paragraphs[len(paragraphs)-1]) {
+		return strings.TrimSpace(msg) + "\n" + line, nil
+	}
+	return strings.TrimSpace(msg) + "\n\n" + line, nil
+}
+
+// currentBranch returns the name of the checked out branch, or "" if HEAD is
+// detached.
+func (a *App) currentBranch() string {
+	// Read HEAD without resolving it, so a branch without commits works too.
+	head, err := a.Repo.Reference(plumbing.HEAD, false)
+	if err != nil || head.Type() != plumbing.SymbolicReference {
+		return ""
+	}
+	return head.Target().Short()
+}
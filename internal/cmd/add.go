@@ -0,0 +1,393 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/doganarif/giq/internal/app"
+	"github.com/spf13/cobra"
+)
+
+// hintsMsg carries the AI descriptions of the hunks.
+type hintsMsg struct {
+	hints []app.HunkHint
+	err   error
+}
+
+// addItem is a hunk in the staging screen with its selected changed lines.
+type addItem struct {
+	hunk *app.Hunk
+	// staged hunks come from the index; selecting them unstages them.
+	staged bool
+	// selected holds the selected changed lines of a text hunk; whole is
+	// used for hunks that can only be staged as a whole file.
+	selected map[int]bool
+	whole    bool
+}
+
+// changes returns the indexes of the changed lines of the hunk.
+func (it *addItem) changes() []int {
+	var idx []int
+	for i, l := range it.hunk.Lines() {
+		if strings.HasPrefix(l, "+") || strings.HasPrefix(l, "-") {
+			idx = append(idx, i)
+		}
+	}
+	return idx
+}
+
+// state returns "x" if the hunk is selected, "~" if some of its lines are,
+// and " " otherwise.
+func (it *addItem) state() string {
+	if it.hunk.Lines() == nil {
+		if it.whole {
+			return "x"
+		}
+		return " "
+	}
+	n := 0
+	for _, sel := range it.selected {
+		if sel {
+			n++
+		}
+	}
+	switch {
+	case n == 0:
+		return " "
+	case n == len(it.changes()):
+		return "x"
+	}
+	return "~"
+}
+
+// toggle selects the whole hunk, or clears it if anything is selected.
+func (it *addItem) toggle() {
+	it.set(it.state() == " ")
+}
+
+func (it *addItem) set(on bool) {
+	if it.hunk.Lines() == nil {
+		it.whole = on
+		return
+	}
+	it.selected = make(map[int]bool)
+	if on {
+		for _, i := range it.changes() {
+			it.selected[i] = true
+		}
+	}
+}
+
+// selection returns what to apply for the hunk, or false if nothing is selected.
+func (it *addItem) selection() (app.HunkSelection, bool) {
+	switch it.state() {
+	case "x":
+		return app.HunkSelection{Hunk: it.hunk}, true
+	case "~":
+		return app.HunkSelection{Hunk: it.hunk, Lines: it.selected}, true
+	}
+	return app.HunkSelection{}, false
+}
+
+// addModel lets the user pick hunks and lines to stage and unstage.
+type addModel struct {
+	app        *app.App
+	items      []*addItem
+	hints      []app.HunkHint
+	describing bool
+	spinner    spinner.Model
+	cursor     int
+	// lineMode shows the lines of the highlighted hunk; lineCursor is an
+	// index into its changed lines.
+	lineMode   bool
+	lineCursor int
+	notice     string
+	confirmed  bool
+}
+
+func initialAddModel(a *app.App, items []*addItem) addModel {
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	return addModel{app: a, items: items, describing: true, spinner: s}
+}
+
+func (m addModel) Init() tea.Cmd {
+	hunks := make([]*app.Hunk, len(m.items))
+	for i, it := range m.items {
+		hunks[i] = it.hunk
+	}
+	describe := func() tea.Msg {
+		hints, err := m.app.DescribeHunks(hunks)
+		return hintsMsg{hints: hints, err: err}
+	}
+	return tea.Batch(m.spinner.Tick, describe)
+}
+
+func (m addModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case hintsMsg:
+		m.describing = false
+		if msg.err != nil {
+			m.notice = fmt.Sprintf("No AI descriptions: %v", msg.err)
+		} else {
+			m.hints = msg.hints
+		}
+		return m, nil
+	case spinner.TickMsg:
+		if !m.describing {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	case tea.KeyMsg:
+		if m.lineMode {
+			return m.updateLines(msg)
+		}
+		return m.updateList(msg)
+	}
+	return m, nil
+}
+
+// updateList handles keys in the list of hunks.
+func (m addModel) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	it := m.items[m.cursor]
+	switch msg.String() {
+	case "ctrl+c", "q", "esc":
+		return m, tea.Quit
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < len(m.items)-1 {
+			m.cursor++
+		}
+	case " ":
+		it.toggle()
+	case "right", "l":
+		if it.hunk.Lines() != nil {
+			m.lineMode, m.lineCursor = true, 0
+		}
+	case "a":
+		// Select or clear every hunk of the same section.
+		on := it.state() == " "
+		for _, other := range m.items {
+			if other.staged == it.staged {
+				other.set(on)
+			}
+		}
+	case "g":
+		// Select or clear the hunks the AI grouped with this one.
+		if group := m.hint(m.cursor).Group; group != "" {
+			on := it.state() == " "
+			for i, other := range m.items {
+				if other.staged == it.staged && strings.EqualFold(m.hint(i).Group, group) {
+					other.set(on)
+				}
+			}
+		}
+	case "enter":
+		m.confirmed = true
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// updateLines handles keys while the lines of a hunk are shown.
+func (m addModel) updateLines(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	it := m.items[m.cursor]
+	changes := it.changes()
+	switch msg.String() {
+	case "ctrl+c", "q":
+		return m, tea.Quit
+	case "esc", "left", "h":
+		m.lineMode = false
+	case "up", "k":
+		if m.lineCursor > 0 {
+			m.lineCursor--
+		}
+	case "down", "j":
+		if m.lineCursor < len(changes)-1 {
+			m.lineCursor++
+		}
+	case " ":
+		if it.selected == nil {
+			it.selected = make(map[int]bool)
+		}
+		i := changes[m.lineCursor]
+		it.selected[i] = !it.selected[i]
+	case "enter":
+		m.confirmed = true
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// hint returns the AI hint of the i-th item, if there is one.
+func (m addModel) hint(i int) app.HunkHint {
+	if i < len(m.hints) {
+		return m.hints[i]
+	}
+	return app.HunkHint{}
+}
+
+func (m addModel) View() string {
+	if m.lineMode {
+		return m.viewLines()
+	}
+
+	var s string
+	for i, it := range m.items {
+		if i == 0 || it.staged != m.items[i-1].staged {
+			if i > 0 {
+				s += "\n"
+			}
+			if it.staged {
+				s += "Staged changes (selected hunks are unstaged):\n"
+			} else {
+				s += "Unstaged changes (selected hunks are staged):\n"
+			}
+		}
+		cursor := "  "
+		if m.cursor == i {
+			cursor = "> "
+		}
+		s += fmt.Sprintf("%s[%s] %s\n", cursor, it.state(), it.hunk)
+		if h := m.hint(i); h.Description != "" {
+			line := "      " + h.Description
+			if h.Group != "" {
+				line += fmt.Sprintf(" (%s)", h.Group)
+			}
+			s += line + "\n"
+		}
+	}
+	if m.describing {
+		s += fmt.Sprintf("\n%s Describing hunks...\n", m.spinner.View())
+	}
+	if m.notice != "" {
+		s += "\n" + m.notice + "\n"
+	}
+	return s + "\nUse ↑/↓ arrows to navigate, space to select a hunk, → to pick lines, a to select all, g to select the group\n" +
+		"enter to apply, q to quit without changes"
+}
+
+// viewLines shows the lines of the highlighted hunk.
+func (m addModel) viewLines() string {
+	it := m.items[m.cursor]
+	changes := it.changes()
+	s := fmt.Sprintf("%s\n\n", it.hunk)
+	for i, l := range it.hunk.Lines() {
+		switch {
+		case strings.HasPrefix(l, "+") || strings.HasPrefix(l, "-"):
+			cursor := "  "
+			if changes[m.lineCursor] == i {
+				cursor = "> "
+			}
+			mark := " "
+			if it.selected[i] {
+				mark = "x"
+			}
+			s += fmt.Sprintf("%s[%s] %s\n", cursor, mark, l)
+		default:
+			s += fmt.Sprintf("      %s\n", l)
+		}
+	}
+	return s + "\nUse ↑/↓ arrows to navigate, space to select a line, ← to go back\nenter to apply, q to quit without changes"
+}
+
+// NewAddCommand creates the add command. "giq add -p" stages hunks and lines
+// interactively; anything else is passed to git add.
+func NewAddCommand(a *app.App) *cobra.Command {
+	return &cobra.Command{
+		Use:   "add -p [--] [pathspec...]",
+		Short: "Stage and unstage hunks interactively with AI descriptions",
+		Long: "With -p or --patch, list the unstaged and staged hunks with a one-line AI description and a suggested " +
+			"grouping of each, and stage or unstage whole hunks or single lines. Untracked files are not listed.\n\n" +
+			"Without -p, or with other git add options, the arguments are passed to git add unchanged.",
+		DisableFlagParsing: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Other git add options cannot be honored by the staging screen.
+			patch, other := false, false
+			var paths []string
+			for i, arg := range args {
+				if arg == "--" {
+					paths = append(paths, args[i+1:]...)
+					break
+				}
+				switch {
+				case arg == "-p" || arg == "--patch":
+					patch = true
+				case strings.HasPrefix(arg, "-"):
+					other = true
+				default:
+					paths = append(paths, arg)
+				}
+			}
+			if !patch || other {
+				return a.ExecGit(append([]string{"add"}, args...)...)
+			}
+			return addPatch(a, paths)
+		},
+	}
+}
+
+// addPatch runs the interactive staging screen for paths.
+func addPatch(a *app.App, paths []string) error {
+	unstaged, err := a.UnstagedHunks(paths)
+	if err != nil {
+		return err
+	}
+	staged, err := a.IndexHunks(paths)
+	if err != nil {
+		return err
+	}
+	if untracked, err := a.UntrackedFiles(); err == nil && untracked > 0 {
+		fmt.Printf("%d untracked file(s) not shown, use git add to track them first.\n", untracked)
+	}
+	if len(unstaged)+len(staged) == 0 {
+		fmt.Println("No changes.")
+		return nil
+	}
+
+	var items []*addItem
+	for _, h := range unstaged {
+		items = append(items, &addItem{hunk: h})
+	}
+	for _, h := range staged {
+		items = append(items, &addItem{hunk: h, staged: true})
+	}
+
+	m, err := tea.NewProgram(initialAddModel(a, items)).Run()
+	if err != nil {
+		return err
+	}
+	am, ok := m.(addModel)
+	if !ok {
+		return fmt.Errorf("unexpected model type")
+	}
+	if !am.confirmed {
+		return nil
+	}
+
+	var toStage, toUnstage []app.HunkSelection
+	for _, it := range am.items {
+		if sel, ok := it.selection(); ok {
+			if it.staged {
+				toUnstage = append(toUnstage, sel)
+			} else {
+				toStage = append(toStage, sel)
+			}
+		}
+	}
+	if err := a.Stage(toStage); err != nil {
+		return err
+	}
+	if err := a.Unstage(toUnstage); err != nil {
+		return err
+	}
+	fmt.Printf("Staged %d hunk(s), unstaged %d.\n", len(toStage), len(toUnstage))
+	return nil
+}
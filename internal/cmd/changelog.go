@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/doganarif/giq/internal/app"
+	"github.com/spf13/cobra"
+)
+
+// NewChangelogCommand creates the changelog command, which writes release
+// notes for the commits between two refs.
+func NewChangelogCommand(a *app.App) *cobra.Command {
+	var format string
+	var version string
+	var prepend bool
+	var file string
+	var noAI bool
+	cmd := &cobra.Command{
+		Use:   "changelog <from>..<to>",
+		Short: "Generate release notes for the commits between two refs",
+		Long: "Generate release notes for the commits reachable from <to> (HEAD if omitted) but not from <from>, " +
+			"such as v1.2.0..HEAD, grouped into breaking changes, features, fixes and other changes.\n\n" +
+			"Conventional Commits are grouped by their type; the AI classifies the others and rewrites every " +
+			"subject as a note for users. With --no-ai, the subjects are used as they are and commits without " +
+			"a conventional type are listed under Other.\n\n" +
+			"The notes are printed in Markdown, or with --format keepachangelog in the Keep a Changelog format. " +
+			"With --prepend they are added to the top of CHANGELOG.md instead.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != "markdown" && format != "keepachangelog" {
+				return fmt.Errorf("unknown format %q, use markdown or keepachangelog", format)
+			}
+			from, to := app.ParseRange(args[0])
+			commits, err := a.CommitsBetween(from, to)
+			if err != nil {
+				return err
+			}
+			if len(commits) == 0 {
+				return fmt.Errorf("no commits in %s", args[0])
+			}
+
+			log := app.Changelog{Version: version}
+			if log.Version == "" {
+				// A release is named after its tag.
+				log.Version = "Unreleased"
+				if _, err := a.Repo.Tag(to); err == nil {
+					log.Version = to
+				}
+			}
+			if log.Version != "Unreleased" {
+				log.Date = commits[0].Committer.When
+			}
+			for _, c := range commits {
+				log.Entries = append(log.Entries, app.ClassifyCommit(c))
+			}
+			if !noAI {
+				// Keep stdout for the notes themselves.
+				fmt.Fprintf(os.Stderr, "Describing %d commit(s)...\n", len(commits))
+				if err := a.DescribeChanges(log.Entries); err != nil {
+					fmt.Fprintf(os.Stderr, "Using the commit subjects, the AI request failed: %v\n", err)
+				}
+			}
+
+			notes := log.Markdown()
+			if format == "keepachangelog" {
+				notes = log.KeepAChangelog()
+			}
+			if !prepend {
+				fmt.Print(notes)
+				return nil
+			}
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(a.RepoRoot(), file)
+			}
+			if err := app.PrependChangelog(file, notes); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Added %d change(s) to %s\n", len(log.Entries), file)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "markdown", "Output format: markdown or keepachangelog")
+	cmd.Flags().StringVar(&version, "version", "", "Heading of the release (default: the <to> tag, or Unreleased)")
+	cmd.Flags().BoolVar(&prepend, "prepend", false, "Add the notes to the top of the changelog file instead of printing them")
+	cmd.Flags().StringVar(&file, "file", "CHANGELOG.md", "Changelog file for --prepend, relative to the repository root")
+	cmd.Flags().BoolVar(&noAI, "no-ai", false, "Use the commit subjects as they are, without AI classification")
+	return cmd
+}
@@ -2,7 +2,9 @@ package cmd
 
 import (
 	"bufio"
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
@@ -10,6 +12,9 @@ import (
 	"github.com/doganarif/giq/internal/app"
 	"github.com/spf13/cobra"
 
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
@@ -69,8 +74,9 @@ func (m fallbackModel) View() string {
 	return s
 }
 
-// handleUnconfiguredAPI presents options to the user when API is not configured
-func handleUnconfiguredAPI(a *app.App) (string, error) {
+// handleUnconfiguredAPI presents options to the user when API is not configured.
+// gitArgs are passed on to git commit.
+func handleUnconfiguredAPI(a *app.App, gitArgs []string) (string, error) {
 	p := tea.NewProgram(initialFallbackModel())
 	m, err := p.Run()
 	if err != nil {
@@ -94,9 +100,13 @@ func handleUnconfiguredAPI(a *app.App) (string, error) {
 		if err != nil {
 			return "", err
 		}
+		// Ticket IDs apply to every commit made through giq, with or without AI.
+		message, err = a.AddTickets(strings.TrimSpace(message))
+		if err != nil {
+			return "", err
+		}
 		// Use system git for commit to ensure signing config is respected
-		message = strings.TrimSpace(message)
-		if err := a.ExecGit("commit", "-m", message); err != nil {
+		if err := a.ExecGit(append([]string{"commit", "-m", message}, gitArgs...)...); err != nil {
 			return "", err
 		}
 		return "", nil
@@ -109,38 +119,255 @@ func handleUnconfiguredAPI(a *app.App) (string, error) {
 	}
 }
 
-// commitModel is the model for selecting AI-generated commit messages
-type commitModel struct {
+// customMessageOption is offered after the AI suggestions in the picker.
+const customMessageOption = "Write custom message"
+
+// suggestionMsg carries a streamed fragment of a suggestion of a batch.
+type suggestionMsg struct {
+	batch int
+	ai.StreamEvent
+}
+
+// suggestionsDoneMsg is sent once all suggestions of a batch are generated or
+// generation failed.
+type suggestionsDoneMsg struct {
+	batch       int
+	suggestions []string
+	err         error
+}
+
+// editorFinishedMsg is sent when the external editor opened on path exits.
+type editorFinishedMsg struct {
+	path string
+	err  error
+}
+
+// generateFunc starts generating the suggestions of a batch in the
+// background. The suggestions are delivered as suggestionMsg and
+// suggestionsDoneMsg. If instruction is set, previous suggestions are
+// revised according to it.
+type generateFunc func(batch int, previous []string, instruction string)
+
+// suggestionBatch is one round of generated suggestions.
+type suggestionBatch struct {
 	choices  []string
-	cursor   int
-	selected int
+	complete []bool
+	// instruction is the refinement that produced the batch, if any.
+	instruction string
+}
+
+// commitModel is the model for selecting AI-generated commit messages.
+// Suggestions are streamed in while the picker is shown; each becomes
+// selectable as soon as it is complete. Only subjects are listed; the body of
+// the suggestion under the cursor can be expanded. A suggestion can be edited
+// inline or in the user's editor before committing. Suggestions can be
+// regenerated or refined with an instruction; earlier batches are kept and
+// can be browsed.
+type commitModel struct {
+	app        *app.App
+	generate   generateFunc
+	batches    []suggestionBatch
+	current    int
+	generating bool
+	spinner    spinner.Model
+	cursor     int
+	expanded   bool
+	editing    bool
+	textarea   textarea.Model
+	refining   bool
+	input      textinput.Model
+	notice     string
+	// message is the chosen commit message; edited is set if the user
+	// changed it, in which case it is committed as is.
+	message string
+	edited  bool
+	err     error
 }
 
-func initialCommitModel(choices []string) commitModel {
+func initialCommitModel(a *app.App, generate generateFunc) commitModel {
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+
+	ta := textarea.New()
+	ta.ShowLineNumbers = false
+	ta.CharLimit = 0
+	ta.SetWidth(80)
+	ta.SetHeight(10)
+
+	ti := textinput.New()
+	ti.Placeholder = "e.g. shorter, mention the migration"
+	ti.Prompt = "/ "
+
 	return commitModel{
-		choices:  choices,
-		selected: -1,
+		app:        a,
+		generate:   generate,
+		batches:    []suggestionBatch{{}},
+		generating: true,
+		spinner:    s,
+		textarea:   ta,
+		input:      ti,
 	}
 }
 
 func (m commitModel) Init() tea.Cmd {
-	return nil
+	generate := m.generate
+	return tea.Batch(m.spinner.Tick, func() tea.Msg {
+		generate(0, nil, "")
+		return nil
+	})
+}
+
+// batch returns the batch of suggestions currently shown.
+func (m commitModel) batch() *suggestionBatch {
+	return &m.batches[m.current]
+}
+
+// isCustom reports whether index i is the custom message option.
+func (m commitModel) isCustom(i int) bool {
+	return i == len(m.batch().choices)
+}
+
+// selectable reports whether the entry under the cursor can be chosen.
+func (m commitModel) selectable() bool {
+	return m.isCustom(m.cursor) || m.batch().complete[m.cursor]
 }
 
 func (m commitModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case spinner.TickMsg:
+		if !m.generating {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+
+	case suggestionMsg:
+		b := &m.batches[msg.batch]
+		for len(b.choices) <= msg.Index {
+			b.choices = append(b.choices, "")
+			b.complete = append(b.complete, false)
+		}
+		b.choices[msg.Index] += msg.Delta
+		if msg.Done {
+			b.complete[msg.Index] = true
+		}
+
+	case suggestionsDoneMsg:
+		m.generating = false
+		b := &m.batches[msg.batch]
+		if msg.err != nil {
+			// Keep whatever finished before the failure, if anything.
+			var choices []string
+			for i, c := range b.choices {
+				if b.complete[i] {
+					choices = append(choices, c)
+				}
+			}
+			if len(choices) == 0 {
+				if msg.batch > 0 {
+					// Fall back to the previous batch.
+					m.batches = m.batches[:msg.batch]
+					m.current, m.cursor = msg.batch-1, 0
+					m.notice = fmt.Sprintf("Generating new suggestions failed: %v", msg.err)
+					return m, nil
+				}
+				if m.editing {
+					// Let the user finish writing their own message.
+					return m, nil
+				}
+				m.err = msg.err
+				return m, tea.Quit
+			}
+			msg.suggestions = choices
+		}
+		b.choices = msg.suggestions
+		b.complete = make([]bool, len(b.choices))
+		for i := range b.complete {
+			b.complete[i] = true
+		}
+		if m.cursor > len(m.batch().choices) {
+			m.cursor = len(m.batch().choices)
+		}
+
+	case tea.WindowSizeMsg:
+		if msg.Width > 4 {
+			m.textarea.SetWidth(min(msg.Width-2, 100))
+		}
+
+	case editorFinishedMsg:
+		if msg.err != nil {
+			os.Remove(msg.path)
+			m.notice = fmt.Sprintf("Editor failed: %v", msg.err)
+			return m, nil
+		}
+		text, err := app.ReadMessageFile(msg.path)
+		if err != nil {
+			m.notice = fmt.Sprintf("Could not read the edited message: %v", err)
+			return m, nil
+		}
+		if text == "" {
+			m.notice = "Empty message, edit discarded."
+			return m, nil
+		}
+		m.message, m.edited = text, true
+		return m, tea.Quit
+
 	case tea.KeyMsg:
+		if m.editing {
+			return m.updateEditing(msg)
+		}
+		if m.refining {
+			return m.updateRefining(msg)
+		}
+		m.notice = ""
 		switch msg.String() {
 		case "up", "k":
 			if m.cursor > 0 {
 				m.cursor--
 			}
 		case "down", "j":
-			if m.cursor < len(m.choices)-1 {
+			if m.cursor < len(m.batch().choices) {
 				m.cursor++
 			}
+		case "tab", "right", "l":
+			m.expanded = !m.expanded
+		case "left", "h":
+			m.expanded = false
+		case "[":
+			if m.current > 0 {
+				m.current, m.cursor = m.current-1, 0
+			}
+		case "]":
+			if m.current < len(m.batches)-1 {
+				m.current, m.cursor = m.current+1, 0
+			}
+		case "r":
+			if !m.generating {
+				return m, m.startBatch("")
+			}
+		case "/":
+			if !m.generating && len(m.batch().choices) > 0 {
+				m.refining = true
+				m.input.SetValue("")
+				return m, m.input.Focus()
+			}
+		case "e":
+			if m.selectable() {
+				return m, m.startEditing(m.cursorMessage())
+			}
+		case "E":
+			if m.selectable() {
+				return m, m.openEditor(m.cursorMessage())
+			}
 		case "enter":
-			m.selected = m.cursor
+			if m.isCustom(m.cursor) {
+				return m, m.startEditing("")
+			}
+			if !m.selectable() {
+				return m, nil
+			}
+			m.message = m.batch().choices[m.cursor]
 			return m, tea.Quit
 		case "q", "ctrl+c":
 			return m, tea.Quit
@@ -149,102 +376,366 @@ func (m commitModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// updateEditing handles keys while the inline editor is shown.
+func (m commitModel) updateEditing(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+s":
+		text := strings.TrimSpace(m.textarea.Value())
+		if text == "" {
+			return m, nil
+		}
+		m.message, m.edited = text, true
+		return m, tea.Quit
+	case "ctrl+e":
+		return m, m.openEditor(m.textarea.Value())
+	case "esc":
+		m.editing = false
+		m.textarea.Blur()
+		return m, nil
+	case "ctrl+c":
+		return m, tea.Quit
+	}
+	var cmd tea.Cmd
+	m.textarea, cmd = m.textarea.Update(msg)
+	return m, cmd
+}
+
+// updateRefining handles keys while the refine instruction is typed.
+func (m commitModel) updateRefining(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		instruction := strings.TrimSpace(m.input.Value())
+		m.refining = false
+		m.input.Blur()
+		if instruction == "" {
+			return m, nil
+		}
+		return m, m.startBatch(instruction)
+	case "esc":
+		m.refining = false
+		m.input.Blur()
+		return m, nil
+	case "ctrl+c":
+		return m, tea.Quit
+	}
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// startBatch generates a new batch of suggestions and shows it. With an
+// instruction, the suggestions of the batch currently shown are refined.
+func (m *commitModel) startBatch(instruction string) tea.Cmd {
+	var previous []string
+	if instruction != "" {
+		previous = m.batch().choices
+	}
+	m.batches = append(m.batches, suggestionBatch{instruction: instruction})
+	m.current, m.cursor = len(m.batches)-1, 0
+	m.expanded = false
+	m.generating = true
+
+	batch, generate := m.current, m.generate
+	return tea.Batch(m.spinner.Tick, func() tea.Msg {
+		generate(batch, previous, instruction)
+		return nil
+	})
+}
+
+// cursorMessage returns the formatted suggestion under the cursor, or "" for
+// the custom message option.
+func (m commitModel) cursorMessage() string {
+	if m.isCustom(m.cursor) {
+		return ""
+	}
+	return app.FormatCommitMessage(m.batch().choices[m.cursor])
+}
+
+// startEditing shows the inline editor pre-filled with text.
+func (m *commitModel) startEditing(text string) tea.Cmd {
+	m.editing = true
+	m.notice = ""
+	m.textarea.SetValue(text)
+	return m.textarea.Focus()
+}
+
+// openEditor suspends the picker and opens text in the user's git editor.
+func (m commitModel) openEditor(text string) tea.Cmd {
+	path, err := app.WriteMessageFile(text)
+	if err != nil {
+		return func() tea.Msg { return editorFinishedMsg{err: err} }
+	}
+	c, err := m.app.EditorCommand(path)
+	if err != nil {
+		return func() tea.Msg { return editorFinishedMsg{path: path, err: err} }
+	}
+	return tea.ExecProcess(c, func(err error) tea.Msg {
+		return editorFinishedMsg{path: path, err: err}
+	})
+}
+
 func (m commitModel) View() string {
+	if m.editing {
+		s := "Edit the commit message:\n\n" + m.textarea.View() + "\n"
+		if m.notice != "" {
+			s += "\n" + m.notice + "\n"
+		}
+		return s + "\nctrl+s to commit, ctrl+e to open in your editor, esc to go back"
+	}
+
+	b := m.batch()
 	s := "Select a commit message:\n\n"
-	for i, choice := range m.choices {
+	if len(m.batches) > 1 {
+		s = fmt.Sprintf("Select a commit message (batch %d/%d", m.current+1, len(m.batches))
+		if b.instruction != "" {
+			s += fmt.Sprintf(", %q", b.instruction)
+		}
+		s += "):\n\n"
+	}
+	if m.generating && m.current == len(m.batches)-1 && len(b.choices) == 0 {
+		s += fmt.Sprintf("  %s Generating suggestions...\n", m.spinner.View())
+	}
+	hasBody := false
+	for i, choice := range b.choices {
 		cursor := "  "
 		if m.cursor == i {
 			cursor = "> "
 		}
-		s += fmt.Sprintf("%s%s\n", cursor, choice)
+		subject, body := app.SplitMessage(choice)
+		if body != "" {
+			hasBody = true
+			if !(m.expanded && m.cursor == i) {
+				subject += fmt.Sprintf(" [+%d lines]", strings.Count(body, "\n")+1)
+			}
+		}
+		if !b.complete[i] {
+			subject += " " + m.spinner.View()
+		}
+		s += fmt.Sprintf("%s%s\n", cursor, subject)
+		if body != "" && m.expanded && m.cursor == i {
+			for _, l := range strings.Split(body, "\n") {
+				s += fmt.Sprintf("    %s\n", l)
+			}
+		}
+	}
+	cursor := "  "
+	if m.isCustom(m.cursor) {
+		cursor = "> "
+	}
+	s += fmt.Sprintf("%s%s\n", cursor, customMessageOption)
+	if m.refining {
+		s += "\nHow should the suggestions change? (enter to apply, esc to cancel)\n" + m.input.View() + "\n"
+		return s
+	}
+	if m.notice != "" {
+		s += "\n" + m.notice + "\n"
+	}
+	if hasBody {
+		s += "\nUse ↑/↓ arrows to navigate, tab to show the body, enter to select, e to edit, E to edit in your editor"
+	} else {
+		s += "\nUse ↑/↓ arrows to navigate, enter to select, e to edit, E to edit in your editor"
+	}
+	s += "\nr to regenerate, / to refine"
+	if len(m.batches) > 1 {
+		s += ", [ and ] to browse earlier suggestions"
 	}
-	s += "\nUse ↑/↓ arrows to navigate, enter to select"
 	return s
 }
 
-// NewCommitCommand creates the commit command with AI-enhanced commit message support
+// NewCommitCommand creates the commit command with AI-enhanced commit message support.
+// Any git commit flags and pathspecs are forwarded to git unchanged.
 func NewCommitCommand(a *app.App) *cobra.Command {
-	var message string
+	var showPrompt bool
+	var noVerifySecrets bool
+	var body bool
 	cmd := &cobra.Command{
-		Use:   "commit",
+		Use:   "commit [git commit options] [--] [pathspec...]",
 		Short: "Create a commit with an AI-generated message from staged changes",
+		Long: "Create a commit with an AI-generated message from the changes it will record.\n\n" +
+			"All git commit options are supported and passed to git unchanged. The message is generated " +
+			"from the staged changes, the working tree for -a or pathspecs, and HEAD's changes for --amend. " +
+			"No message is generated when one is given with -m, -F, -C, -c, --fixup, --squash or --no-edit.",
+		// git commit options are parsed by parseCommitArgs, which leaves
+		// unknown ones for git.
+		DisableFlagParsing: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// If message flag is provided, use it directly with system git
-			if message != "" {
-				return a.ExecGit("commit", "-m", message)
+			// Only the command's own flags are taken; inherited ones such as
+			// --verbose are git commit options too and go to git.
+			c, err := parseCommitArgs(args, cmd.LocalNonPersistentFlags())
+			if err != nil {
+				return err
+			}
+			if c.help {
+				return cmd.Help()
+			}
+			gitCommit := func(extra ...string) error {
+				return a.ExecGit(append(append([]string{"commit"}, extra...), c.gitArgs...)...)
 			}
 
-			// Show staged files
-			stagedFiles, err := a.GetStagedFiles()
+			// Let git handle the message when it is given or git picks the changes itself.
+			if c.hasMessage || c.interactive {
+				if !noVerifySecrets && !c.interactive {
+					diff, _, err := a.CommitDiff(c.diff)
+					if err != nil {
+						return err
+					}
+					if err := checkSecrets(a, diff); err != nil {
+						return err
+					}
+				}
+				if len(c.messages) > 0 {
+					// git joins several -m values as separate paragraphs.
+					msg, err := a.AddTickets(strings.Join(c.messages, "\n\n"))
+					if err != nil {
+						return err
+					}
+					return gitCommit("-m", msg)
+				}
+				return gitCommit()
+			}
+
+			if body {
+				a.Config.CommitBody = true
+			}
+
+			// Get the changes the commit will record.
+			diff, stagedFiles, err := a.CommitDiff(c.diff)
 			if err != nil {
 				return err
 			}
+
+			// Show staged files
 			fmt.Println("Staged files:")
 			fmt.Println(strings.TrimSpace(stagedFiles))
 			fmt.Println("----------")
 
-			// Get the diff
-			diff, err := a.GetDiff()
-			if err != nil {
-				return err
-			}
 			if strings.TrimSpace(diff) == "" {
 				return fmt.Errorf("no staged changes detected")
 			}
 
+			// Apply privacy rules and condense large diffs before anything leaves the machine.
+			prepared, err := a.PrepareDiff(diff)
+			if err != nil {
+				return err
+			}
+			printDiffNotes(os.Stdout, prepared)
+
 			// Try to generate AI suggestions
-			prompt := fmt.Sprintf(
-				"Generate a single line, concise, and descriptive git commit message summarizing the staged changes on the following files: %s. "+
-					"Do not include bullet points, extra formatting, or multiple lines. Diff:\n%s",
-				strings.TrimSpace(stagedFiles), diff,
-			)
+			prompt := a.CommitPrompt(stagedFiles, prepared.Text)
 
-			suggestions, err := ai.GenerateCommitMessages(a.Config, prompt)
+			if showPrompt {
+				fmt.Println(prompt)
+				return nil
+			}
+
+			// Refuse to go any further with leaked credentials in the diff.
+			if !noVerifySecrets {
+				if err := checkSecrets(a, diff); err != nil {
+					return err
+				}
+			}
+
+			commitMsg, err := pickMessage(a, stagedFiles, prompt)
 			if err != nil {
 				// Handle unconfigured API case
 				if strings.Contains(err.Error(), "API key is not configured") {
-					_, err := handleUnconfiguredAPI(a)
+					_, err := handleUnconfiguredAPI(a, c.gitArgs)
 					return err
 				}
 				return err
 			}
 
-			// Add custom message option
-			suggestions = append(suggestions, "Write custom message")
-
-			// Show commit message selection UI
-			p := tea.NewProgram(initialCommitModel(suggestions))
-			m, err := p.Run()
+			commitMsg, err = a.AddTickets(commitMsg)
 			if err != nil {
 				return err
 			}
+			return gitCommit("-m", commitMsg)
+		},
+	}
 
-			cm, ok := m.(commitModel)
-			if !ok {
-				return fmt.Errorf("unexpected model type")
-			}
+	cmd.Flags().BoolVar(&showPrompt, "show-prompt", false, "Print the prompt that would be sent to the AI and exit")
+	cmd.Flags().BoolVar(&body, "body", false, "Generate a message body explaining why, not just a subject line")
+	cmd.Flags().BoolVar(&noVerifySecrets, "no-verify-secrets", false, "Commit even if the staged changes appear to contain secrets")
+	return cmd
+}
 
-			if cm.selected == -1 {
-				return fmt.Errorf("no commit message selected")
-			}
+// pickMessage streams suggestions for prompt into the picker and returns the
+// message the user chose. Suggestions are formatted; messages the user wrote
+// or edited are returned as they are.
+func pickMessage(a *app.App, files, prompt string) (string, error) {
+	// Stream suggestions into the picker while they are generated.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
-			commitMsg := suggestions[cm.selected]
+	var p *tea.Program
+	generate := func(batch int, previous []string, instruction string) {
+		go func() {
+			messages := ai.CommitConversation(prompt, previous, instruction)
+			suggestions, err := a.StreamCommitMessages(ctx, files, messages, func(ev ai.StreamEvent) {
+				p.Send(suggestionMsg{batch: batch, StreamEvent: ev})
+			})
+			p.Send(suggestionsDoneMsg{batch: batch, suggestions: suggestions, err: err})
+		}()
+	}
+	p = tea.NewProgram(initialCommitModel(a, generate))
 
-			// Handle custom message option
-			if commitMsg == "Write custom message" {
-				fmt.Print("\nEnter your commit message: ")
-				reader := bufio.NewReader(os.Stdin)
-				customMsg, err := reader.ReadString('\n')
-				if err != nil {
-					return err
-				}
-				commitMsg = strings.TrimSpace(customMsg)
-			}
+	m, err := p.Run()
+	// Stop any generation still in progress once a choice is made.
+	cancel()
+	if err != nil {
+		return "", err
+	}
 
-			return a.ExecGit("commit", "-m", commitMsg)
-		},
+	cm, ok := m.(commitModel)
+	if !ok {
+		return "", fmt.Errorf("unexpected model type")
+	}
+	if cm.err != nil {
+		return "", cm.err
+	}
+	if cm.message == "" {
+		return "", fmt.Errorf("no commit message selected")
 	}
 
-	cmd.Flags().StringVarP(&message, "message", "m", "", "Commit message (overrides AI suggestions)")
-	return cmd
+	// Messages written or edited by the user are committed as they are.
+	if cm.edited {
+		return cm.message, nil
+	}
+	return app.FormatCommitMessage(cm.message), nil
+}
+
+// printDiffNotes tells the user how the diff was altered before being sent to the AI.
+func printDiffNotes(w io.Writer, prepared app.PreparedDiff) {
+	if prepared.Redactions > 0 || len(prepared.Excluded) > 0 {
+		fmt.Fprintf(w, "Redacted %d secret value(s) and excluded %d file(s) from the AI prompt.\n", prepared.Redactions, len(prepared.Excluded))
+	}
+	if prepared.Reduced() {
+		fmt.Fprintf(w, "Diff condensed from ~%d to ~%d tokens to fit the model's context.\n", prepared.OriginalTokens, prepared.Tokens)
+	}
+}
+
+// checkSecrets scans the staged diff for credentials and returns an error
+// listing where they were found, if any.
+func checkSecrets(a *app.App, diff string) error {
+	findings, err := a.ScanSecrets(diff)
+	if err != nil {
+		return err
+	}
+	if len(findings) == 0 {
+		return nil
+	}
+
+	printSecretFindings(findings)
+	fmt.Fprintln(os.Stderr, "or rerun with --no-verify-secrets to commit anyway.")
+	return fmt.Errorf("commit blocked: %d potential secret(s) in staged changes", len(findings))
+}
+
+// printSecretFindings lists potential secrets on stderr with how to resolve
+// false positives.
+func printSecretFindings(findings []app.SecretFinding) {
+	fmt.Fprintln(os.Stderr, "Potential secrets found in staged changes:")
+	for _, f := range findings {
+		fmt.Fprintf(os.Stderr, "  %s\n", f)
+	}
+	fmt.Fprintf(os.Stderr, "\nRemove them, add false positives to %s or mark the line with %q,\n", app.SecretsAllowlistFile, app.SecretAllowMarker)
 }
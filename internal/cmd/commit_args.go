@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/doganarif/giq/internal/app"
+	"github.com/spf13/pflag"
+)
+
+// commitArgs is a giq commit command line split into giq's own flags and the
+// arguments forwarded unchanged to git commit.
+type commitArgs struct {
+	gitArgs []string
+	diff    app.DiffOptions
+	// hasMessage is set if git gets the message some other way (-m, -F, -C,
+	// --fixup, --no-edit, ...), so no suggestions are needed.
+	hasMessage bool
+	// messages are the values of -m options. They are taken out of gitArgs
+	// so the combined message can be passed on with ticket IDs added.
+	messages []string
+	// interactive is set for options that make git decide what to commit or
+	// ask for a message itself (--patch, --interactive, --dry-run).
+	interactive bool
+	help        bool
+}
+
+// Short options of git commit that take a value, and those whose value is
+// optional and can only be attached (-S<keyid>, -u<mode>).
+const (
+	shortValueOptions         = "CcFmt"
+	shortOptionalValueOptions = "Su"
+)
+
+// longValueOptions are the long options of git commit that take a value,
+// which may be given as a separate argument.
+var longValueOptions = map[string]bool{
+	"message": true, "file": true, "reuse-message": true, "reedit-message": true,
+	"template": true, "author": true, "date": true, "fixup": true, "squash": true,
+	"cleanup": true, "trailer": true, "pathspec-from-file": true,
+}
+
+// messageOptions provide the commit message, in short or long form.
+var messageOptions = map[string]bool{
+	"m": true, "F": true, "C": true, "c": true,
+	"message": true, "file": true, "reuse-message": true, "reedit-message": true,
+	"fixup": true, "squash": true, "no-edit": true,
+}
+
+// interactiveOptions make git choose the changes or skip committing.
+var interactiveOptions = map[string]bool{
+	"p": true, "patch": true, "interactive": true, "dry-run": true,
+}
+
+// parseCommitArgs separates the flags defined in giq's flag set from the git
+// commit arguments and works out which changes the commit will record.
+func parseCommitArgs(args []string, flags *pflag.FlagSet) (commitArgs, error) {
+	var c commitArgs
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			c.gitArgs = append(c.gitArgs, args[i:]...)
+			c.diff.Pathspecs = append(c.diff.Pathspecs, args[i+1:]...)
+			return c, nil
+
+		case arg == "-h" || arg == "--help":
+			c.help = true
+
+		case strings.HasPrefix(arg, "--"):
+			name, value, hasValue := strings.Cut(arg[2:], "=")
+			if f := flags.Lookup(name); f != nil {
+				// One of giq's own flags.
+				if !hasValue {
+					value = "true"
+				}
+				if err := f.Value.Set(value); err != nil {
+					return c, err
+				}
+				continue
+			}
+			c.noteOption(name)
+			if name == "message" {
+				if !hasValue && i+1 < len(args) {
+					i++
+					value = args[i]
+				}
+				c.messages = append(c.messages, value)
+				continue
+			}
+			c.gitArgs = append(c.gitArgs, arg)
+			if longValueOptions[name] && !hasValue && i+1 < len(args) {
+				i++
+				c.gitArgs = append(c.gitArgs, args[i])
+			}
+
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			// A cluster of short options such as -am or -sS<keyid>.
+			cluster, separate := arg, false
+			for j := 1; j < len(arg); j++ {
+				opt := arg[j : j+1]
+				c.noteOption(opt)
+				if strings.Contains(shortOptionalValueOptions, opt) {
+					break
+				}
+				if strings.Contains(shortValueOptions, opt) {
+					value := arg[j+1:]
+					if value == "" && i+1 < len(args) {
+						i++
+						value, separate = args[i], true
+					}
+					if opt == "m" {
+						// Keep the options before -m, such as the a of -am.
+						c.messages = append(c.messages, value)
+						cluster, separate = arg[:j], false
+					}
+					break
+				}
+			}
+			if cluster != "-" {
+				c.gitArgs = append(c.gitArgs, cluster)
+			}
+			if separate {
+				c.gitArgs = append(c.gitArgs, args[i])
+			}
+
+		default:
+			// Anything else is a pathspec.
+			c.gitArgs = append(c.gitArgs, arg)
+			c.diff.Pathspecs = append(c.diff.Pathspecs, arg)
+		}
+	}
+	return c, nil
+}
+
+// noteOption records what a git commit option means for the diff and the
+// message.
+func (c *commitArgs) noteOption(name string) {
+	switch name {
+	case "a", "all":
+		c.diff.All = true
+	case "amend":
+		c.diff.Amend = true
+	case "i", "include":
+		c.diff.Include = true
+	}
+	if messageOptions[name] {
+		c.hasMessage = true
+	}
+	if interactiveOptions[name] {
+		c.interactive = true
+	}
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/doganarif/giq/internal/app"
+	"github.com/spf13/cobra"
+)
+
+// NewHookCommand creates the hook command which manages the
+// prepare-commit-msg hook that suggests messages for plain "git commit".
+func NewHookCommand(a *app.App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "hook",
+		Short: "Suggest commit messages when running git commit directly",
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "install",
+		Short: "Install the " + app.CommitMsgHook + " hook in the current repository",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			chained, err := a.InstallHook()
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Installed the %s hook. Plain git commit now starts with an AI suggestion.\n", app.CommitMsgHook)
+			if chained != "" {
+				fmt.Printf("The existing hook was moved to %s and still runs first.\n", chained)
+			}
+			return nil
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "uninstall",
+		Short: "Remove the " + app.CommitMsgHook + " hook and restore any hook it replaced",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := a.UninstallHook(); err != nil {
+				return err
+			}
+			fmt.Printf("Removed the %s hook.\n", app.CommitMsgHook)
+			return nil
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:    app.CommitMsgHook + " <file> [source] [sha]",
+		Short:  "Write a suggested message into the commit message file (called by git)",
+		Hidden: true,
+		Args:   cobra.RangeArgs(1, 3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			file := args[0]
+			// Messages given with -m or -F only get the branch's ticket IDs.
+			if len(args) > 1 && args[1] == "message" {
+				return a.AddTicketsToFile(file)
+			}
+			// Only plain "git commit" has no source. Merges, squashes,
+			// amends, -c/-C and templates already have a message.
+			if len(args) > 1 && args[1] != "" {
+				return nil
+			}
+			if has, err := app.HasMessage(file); err != nil || has {
+				return err
+			}
+
+			files, err := a.GetStagedFiles()
+			if err != nil || strings.TrimSpace(files) == "" {
+				return err
+			}
+
+			// Never send leaked credentials to the AI, but leave the decision
+			// to commit them to the user.
+			diff, err := a.GetDiff()
+			if err != nil {
+				return err
+			}
+			findings, err := a.ScanSecrets(diff)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "giq: not generating a commit message, the secret scan failed: %v\n", err)
+				return nil
+			}
+			if len(findings) > 0 {
+				printSecretFindings(findings)
+				fmt.Fprintln(os.Stderr, "so that giq can suggest a message. The commit itself is not blocked.")
+				return nil
+			}
+
+			fmt.Fprintln(os.Stderr, "giq: generating a commit message...")
+			msg, err := a.GenerateCommitMessage()
+			if err != nil {
+				// Never block the commit, the user can still write a message.
+				fmt.Fprintf(os.Stderr, "giq: could not generate a commit message: %v\n", err)
+				return nil
+			}
+			msg, err = a.AddTickets(app.FormatCommitMessage(msg))
+			if err != nil {
+				return err
+			}
+			return app.PrependMessage(file, msg)
+		},
+	})
+
+	return cmd
+}
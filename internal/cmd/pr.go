@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/doganarif/giq/internal/app"
+	"github.com/spf13/cobra"
+)
+
+// NewPRCommand creates the pr command, which writes a pull request title and
+// description for the current branch.
+func NewPRCommand(a *app.App) *cobra.Command {
+	var base string
+	var output string
+	var showPrompt bool
+	cmd := &cobra.Command{
+		Use:   "pr",
+		Short: "Generate a pull request title and description for the current branch",
+		Long: "Generate a Markdown pull request title and description from the commits and the cumulative diff " +
+			"of the current branch since it diverged from the target branch. If the repository has a pull request " +
+			"template, such as .github/pull_request_template.md, it is filled in; otherwise the description has " +
+			"summary, changes, testing and risk sections.\n\n" +
+			"The title is printed on the first line, followed by a blank line and the description, so the " +
+			"output can be piped to a forge CLI, for example:\n\n" +
+			"  giq pr > pr.md && gh pr create --title \"$(head -n1 pr.md)\" --body \"$(tail -n +3 pr.md)\"",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if base == "" {
+				var err error
+				if base, err = a.DefaultBase(); err != nil {
+					return err
+				}
+			}
+			changes, err := a.BranchChanges(base)
+			if err != nil {
+				return err
+			}
+			if len(changes.Commits) == 0 || strings.TrimSpace(changes.Diff) == "" {
+				return fmt.Errorf("the branch has no changes since %s", base)
+			}
+
+			prepared, err := a.PrepareDiff(changes.Diff)
+			if err != nil {
+				return err
+			}
+			prompt := a.PRPrompt(changes, prepared.Text)
+			if showPrompt {
+				fmt.Println(prompt)
+				return nil
+			}
+
+			// Keep stdout for the pull request itself.
+			printDiffNotes(os.Stderr, prepared)
+			fmt.Fprintf(os.Stderr, "Describing %d commit(s) since %s...\n", len(changes.Commits), base)
+
+			pr, err := a.GeneratePullRequest(prompt)
+			if err != nil {
+				return err
+			}
+			if output == "" || output == "-" {
+				fmt.Print(pr)
+				return nil
+			}
+			if err := os.WriteFile(output, []byte(pr.String()), 0644); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Wrote %s\n", output)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&base, "base", "b", "", "Target branch of the pull request (default: origin/HEAD, main or master)")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Write the pull request to a file instead of stdout")
+	cmd.Flags().BoolVar(&showPrompt, "show-prompt", false, "Print the prompt that would be sent to the AI and exit")
+	return cmd
+}
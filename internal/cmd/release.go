@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/doganarif/giq/internal/app"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/spf13/cobra"
+)
+
+// NewReleaseCommand creates the release command, which proposes the next
+// semantic version and tags it.
+func NewReleaseCommand(a *app.App) *cobra.Command {
+	var pre string
+	var dryRun bool
+	var noAI bool
+	var yes bool
+	cmd := &cobra.Command{
+		Use:   "release",
+		Short: "Propose the next semantic version and create an annotated tag for it",
+		Long: "Find the latest semantic version tag before HEAD, analyze the commits since then and propose the next " +
+			"version: major for breaking changes, minor for features, patch otherwise. Before 1.0.0, breaking " +
+			"changes bump the minor version.\n\n" +
+			"Conventional Commits are classified by their type and breaking-change markers; the AI classifies the " +
+			"others and writes the release notes of the tag message. After confirmation, an annotated tag is " +
+			"created at HEAD with git, so tag signing settings apply. The tag is not pushed.\n\n" +
+			"With --pre rc, the version gets a numbered pre-release suffix such as v1.3.0-rc.1, counting up " +
+			"from an earlier pre-release of the same version.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			versions, err := a.ReleaseTags()
+			if err != nil {
+				return err
+			}
+			latest, stable := app.LatestVersions(versions)
+
+			// The bump is measured from the last stable release, even across
+			// pre-releases of the next one.
+			commits, err := a.CommitsBetween(stable.Tag, "HEAD")
+			if err != nil {
+				return err
+			}
+			unreleased := make(map[plumbing.Hash]bool)
+			since := commits
+			if latest.Tag != stable.Tag {
+				if since, err = a.CommitsBetween(latest.Tag, "HEAD"); err != nil {
+					return err
+				}
+			}
+			for _, c := range since {
+				unreleased[c.Hash] = true
+			}
+			if len(unreleased) == 0 {
+				return fmt.Errorf("no commits since %s, there is nothing to release", latest.Tag)
+			}
+
+			entries := make([]app.ChangeEntry, len(commits))
+			for i, c := range commits {
+				entries[i] = app.ClassifyCommit(c)
+			}
+			if !noAI {
+				fmt.Printf("Analyzing %d commit(s)...\n", len(commits))
+				if err := a.DescribeChanges(entries); err != nil {
+					fmt.Fprintf(os.Stderr, "Classifying by commit type only, the AI request failed: %v\n", err)
+				}
+			} else {
+				for i := range entries {
+					if entries[i].Section == "" {
+						entries[i].Section = app.SectionOther
+					}
+				}
+			}
+
+			plan, err := app.ProposeRelease(latest, stable, entries, unreleased, pre)
+			if err != nil {
+				return err
+			}
+			if latest.Tag == "" {
+				fmt.Println("No release tags yet, starting from 0.0.0.")
+			} else {
+				fmt.Printf("Latest release: %s\n", latest.Tag)
+			}
+			fmt.Printf("Next release:   %s (%s)\n", plan.Next, plan.Bump)
+			for _, reason := range plan.Reasons {
+				fmt.Printf("  - %s\n", reason)
+			}
+			message := plan.TagMessage()
+			fmt.Printf("\nTag message:\n\n%s\n\n", indent(message, "    "))
+
+			if dryRun {
+				fmt.Println("Dry run, no tag was created.")
+				return nil
+			}
+			if !yes {
+				fmt.Printf("Create the annotated tag %s at HEAD? [y/N] ", plan.Next)
+				answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+				if err != nil && answer == "" {
+					return err
+				}
+				if reply := strings.ToLower(strings.TrimSpace(answer)); reply != "y" && reply != "yes" {
+					return fmt.Errorf("release aborted, no tag was created")
+				}
+			}
+			if err := a.CreateTag(plan.Next.String(), message); err != nil {
+				return err
+			}
+			fmt.Printf("Created tag %s. Publish it with: git push origin %s\n", plan.Next, plan.Next)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&pre, "pre", "", "Pre-release label, such as rc or beta, for a version like v1.3.0-rc.1")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show the proposed version and tag message without creating the tag")
+	cmd.Flags().BoolVar(&noAI, "no-ai", false, "Classify commits by their conventional type only, without AI")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Create the tag without asking for confirmation")
+	return cmd
+}
+
+// indent prefixes every non-empty line of s.
+func indent(s, prefix string) string {
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		if l != "" {
+			lines[i] = prefix + l
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/doganarif/giq/internal/app"
+	"github.com/spf13/cobra"
+)
+
+// NewRewordCommand creates the reword command which replaces the message of
+// an existing commit with an AI-generated one.
+func NewRewordCommand(a *app.App) *cobra.Command {
+	var message string
+	var showPrompt bool
+	var body bool
+	cmd := &cobra.Command{
+		Use:   "reword [<rev>]",
+		Short: "Rewrite the message of a commit (HEAD by default) with an AI-generated one",
+		Long: "Rewrite the message of a commit with an AI-generated one, based on the commit's changes.\n\n" +
+			"HEAD is amended. Older commits are rewritten with an interactive rebase that runs without " +
+			"prompting; if it fails, it is aborted and the branch is left untouched. The working tree " +
+			"must be clean and no merge commits may follow the commit.",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			rev := "HEAD"
+			if len(args) == 1 {
+				rev = args[0]
+			}
+			if body {
+				a.Config.CommitBody = true
+			}
+
+			commit, diff, files, err := a.CommitChanges(rev)
+			if err != nil {
+				return err
+			}
+			subject, _ := app.SplitMessage(commit.Message)
+			fmt.Printf("Rewording %s %s\n", commit.Hash.String()[:7], subject)
+
+			if message == "" {
+				if strings.TrimSpace(diff) == "" {
+					return fmt.Errorf("commit %s has no changes to describe", commit.Hash.String()[:7])
+				}
+				prepared, err := a.PrepareDiff(diff)
+				if err != nil {
+					return err
+				}
+				printDiffNotes(os.Stdout, prepared)
+
+				prompt := a.CommitPrompt(files, prepared.Text) +
+					fmt.Sprintf("\n\nThe commit's current message, which should be improved, is:\n%s", strings.TrimSpace(commit.Message))
+				if showPrompt {
+					fmt.Println(prompt)
+					return nil
+				}
+
+				message, err = pickMessage(a, files, prompt)
+				if err != nil {
+					return err
+				}
+			}
+
+			message, err = a.AddTickets(message)
+			if err != nil {
+				return err
+			}
+			if err := a.Reword(commit.Hash.String(), message); err != nil {
+				return err
+			}
+			fmt.Printf("Reworded %s\n", commit.Hash.String()[:7])
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&message, "message", "m", "", "New commit message (overrides AI suggestions)")
+	cmd.Flags().BoolVar(&showPrompt, "show-prompt", false, "Print the prompt that would be sent to the AI and exit")
+	cmd.Flags().BoolVar(&body, "body", false, "Generate a message body explaining why, not just a subject line")
+	return cmd
+}
@@ -22,10 +22,19 @@ func NewRootCommand(a *app.App) *cobra.Command {
 		},
 	}
 
+	rootCmd.PersistentFlags().BoolVar(&a.Config.Verbose, "verbose", a.Config.Verbose, "Report which AI provider answered")
+
 	// Register giq-specific subcommands.
 	rootCmd.AddCommand(NewCommitCommand(a))
 	rootCmd.AddCommand(NewStatusCommand(a))
 	rootCmd.AddCommand(NewSetupCommand())
+	rootCmd.AddCommand(NewHookCommand(a))
+	rootCmd.AddCommand(NewRewordCommand(a))
+	rootCmd.AddCommand(NewSplitCommand(a))
+	rootCmd.AddCommand(NewAddCommand(a))
+	rootCmd.AddCommand(NewPRCommand(a))
+	rootCmd.AddCommand(NewChangelogCommand(a))
+	rootCmd.AddCommand(NewReleaseCommand(a))
 
 	return rootCmd
 }
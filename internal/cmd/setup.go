@@ -33,14 +33,49 @@ func NewSetupCommand() *cobra.Command {
 			configFile := filepath.Join(configDir, "config.yaml")
 			// Create YAML content based on provider.
 			var content string
-			if cfg.AIProvider == "openai" {
+			switch cfg.AIProvider {
+			case "openai":
 				content = fmt.Sprintf("ai_provider: openai\nai_key: %s\n", cfg.AIKey)
-			} else {
+				if cfg.BaseURL != "" {
+					content += fmt.Sprintf("base_url: %s\n", cfg.BaseURL)
+				}
+				if cfg.Model != "" {
+					content += fmt.Sprintf("model: %s\n", cfg.Model)
+				}
+			case "anthropic":
+				content = fmt.Sprintf("ai_provider: anthropic\nanthropic_api_key: %s\n", cfg.AnthropicAPIKey)
+				if cfg.AnthropicModel != "" {
+					content += fmt.Sprintf("anthropic_model: %s\n", cfg.AnthropicModel)
+				}
+			case "ollama":
+				content = "ai_provider: ollama\n"
+				if cfg.OllamaHost != "" {
+					content += fmt.Sprintf("ollama_host: %s\n", cfg.OllamaHost)
+				}
+				if cfg.OllamaModel != "" {
+					content += fmt.Sprintf("ollama_model: %s\n", cfg.OllamaModel)
+				}
+			case "gemini":
+				content = fmt.Sprintf("ai_provider: gemini\ngemini_api_key: %s\n", cfg.GeminiAPIKey)
+				if cfg.GeminiModel != "" {
+					content += fmt.Sprintf("gemini_model: %s\n", cfg.GeminiModel)
+				}
+			default:
 				content = fmt.Sprintf(
 					"ai_provider: azure_openai\nazure_endpoint: %s\nazure_deployment_id: %s\nazure_api_key: %s\nazure_api_version: %s\n",
 					cfg.AzureEndpoint, cfg.AzureDeploymentID, cfg.AzureAPIKey, cfg.AzureAPIVersion,
 				)
 			}
+			// Request settings are written only if they differ from the defaults.
+			if cfg.Temperature != 0.5 {
+				content += fmt.Sprintf("temperature: %g\n", cfg.Temperature)
+			}
+			if cfg.MaxTokens > 0 {
+				content += fmt.Sprintf("max_tokens: %d\n", cfg.MaxTokens)
+			}
+			if cfg.Candidates != 3 {
+				content += fmt.Sprintf("candidates: %d\n", cfg.Candidates)
+			}
 			if err := os.WriteFile(configFile, []byte(content), 0644); err != nil {
 				return err
 			}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/textinput"
@@ -29,9 +30,64 @@ type setupModel struct {
 	err          error
 }
 
+// optionalFields may be left empty in the wizard to keep the provider default.
+var optionalFields = map[string]bool{
+	"Base URL":     true,
+	"Model":        true,
+	"Ollama Host":  true,
+	"Ollama Model": true,
+	"Temperature":  true,
+	"Max Tokens":   true,
+	"Candidates":   true,
+}
+
+// requestFields are the request settings asked for after every provider's
+// own fields.
+var requestFields = []string{"Temperature", "Max Tokens", "Candidates"}
+
+// validateField checks the value entered for a field with a numeric setting.
+func validateField(field, value string) error {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	switch field {
+	case "Temperature":
+		t, err := strconv.ParseFloat(value, 32)
+		if err != nil || t < 0 || t > 2 {
+			return fmt.Errorf("temperature must be a number between 0 and 2")
+		}
+	case "Max Tokens", "Candidates":
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return fmt.Errorf("%s must be a positive whole number", strings.ToLower(field))
+		}
+	}
+	return nil
+}
+
+// fieldPlaceholder returns the input placeholder for a wizard field.
+func fieldPlaceholder(field string) string {
+	if optionalFields[field] {
+		return "Enter " + field + " (optional, ENTER for default)"
+	}
+	return "Enter " + field
+}
+
 func getFieldsForProvider(provider string) []string {
-	if provider == "openai" {
-		return []string{"OpenAI API Key"}
+	return append(providerFields(provider), requestFields...)
+}
+
+func providerFields(provider string) []string {
+	switch provider {
+	case "openai":
+		return []string{"OpenAI API Key", "Base URL", "Model"}
+	case "anthropic":
+		return []string{"Anthropic API Key", "Model"}
+	case "ollama":
+		return []string{"Ollama Host", "Ollama Model"}
+	case "gemini":
+		return []string{"Gemini API Key", "Model"}
 	}
 	return []string{
 		"Azure Endpoint",
@@ -70,14 +126,35 @@ func (m setupModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.step = stepInputCredential
 				fields := getFieldsForProvider(m.provider)
 				m.currentField = fields[0]
-				m.input.Placeholder = "Enter " + m.currentField
+				m.input.Placeholder = fieldPlaceholder(m.currentField)
 				return m, nil
 			case "2":
 				m.provider = "azure_openai"
 				m.step = stepInputCredential
 				fields := getFieldsForProvider(m.provider)
 				m.currentField = fields[0]
-				m.input.Placeholder = "Enter " + m.currentField
+				m.input.Placeholder = fieldPlaceholder(m.currentField)
+				return m, nil
+			case "3":
+				m.provider = "anthropic"
+				m.step = stepInputCredential
+				fields := getFieldsForProvider(m.provider)
+				m.currentField = fields[0]
+				m.input.Placeholder = fieldPlaceholder(m.currentField)
+				return m, nil
+			case "4":
+				m.provider = "ollama"
+				m.step = stepInputCredential
+				fields := getFieldsForProvider(m.provider)
+				m.currentField = fields[0]
+				m.input.Placeholder = fieldPlaceholder(m.currentField)
+				return m, nil
+			case "5":
+				m.provider = "gemini"
+				m.step = stepInputCredential
+				fields := getFieldsForProvider(m.provider)
+				m.currentField = fields[0]
+				m.input.Placeholder = fieldPlaceholder(m.currentField)
 				return m, nil
 			case "q", "ctrl+c":
 				return m, tea.Quit
@@ -86,7 +163,10 @@ func (m setupModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case stepInputCredential:
 			switch msg.String() {
 			case "enter":
-				if strings.TrimSpace(m.input.Value()) == "" {
+				if strings.TrimSpace(m.input.Value()) == "" && !optionalFields[m.currentField] {
+					return m, nil
+				}
+				if m.err = validateField(m.currentField, m.input.Value()); m.err != nil {
 					return m, nil
 				}
 
@@ -106,7 +186,7 @@ func (m setupModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 
 				m.currentField = fields[m.fieldIndex]
-				m.input.Placeholder = "Enter " + m.currentField
+				m.input.Placeholder = fieldPlaceholder(m.currentField)
 				return m, nil
 
 			case "esc":
@@ -147,8 +227,11 @@ func (m setupModel) View() string {
 	case stepProviderSelection:
 		s.WriteString("Select AI Provider:\n\n")
 		s.WriteString("1. OpenAI\n")
-		s.WriteString("2. Azure OpenAI\n\n")
-		s.WriteString("Press 1 or 2 to select a provider (ESC to cancel)\n")
+		s.WriteString("2. Azure OpenAI\n")
+		s.WriteString("3. Anthropic\n")
+		s.WriteString("4. Ollama (local)\n")
+		s.WriteString("5. Google Gemini\n\n")
+		s.WriteString("Press 1-5 to select a provider (ESC to cancel)\n")
 
 	case stepInputCredential:
 		s.WriteString(fmt.Sprintf("Setting up %s\n\n", strings.Title(m.provider)))
@@ -161,6 +244,9 @@ func (m setupModel) View() string {
 			} else if i == m.fieldIndex {
 				s.WriteString(fmt.Sprintf("\n> %s:\n", field))
 				s.WriteString(m.input.View())
+				if m.err != nil {
+					s.WriteString(fmt.Sprintf("\n%v", m.err))
+				}
 				s.WriteString("\n\nPress ENTER to confirm (ESC to start over)\n")
 			} else {
 				s.WriteString(fmt.Sprintf("  %s\n", field))
@@ -171,8 +257,12 @@ func (m setupModel) View() string {
 		s.WriteString("Please confirm your configuration:\n\n")
 		s.WriteString(fmt.Sprintf("AI Provider: %s\n", strings.Title(m.provider)))
 		for field, value := range m.answers {
-			masked := strings.Contains(strings.ToLower(field), "key") || strings.Contains(strings.ToLower(field), "token")
-			if masked {
+			if value == "" {
+				s.WriteString(fmt.Sprintf("%s: (default)\n", field))
+				continue
+			}
+			masked := strings.Contains(strings.ToLower(field), "key")
+			if masked && len(value) > 4 {
 				s.WriteString(fmt.Sprintf("%s: %s%s\n", field, value[:4], strings.Repeat("*", len(value)-4)))
 			} else {
 				s.WriteString(fmt.Sprintf("%s: %s\n", field, value))
@@ -208,14 +298,37 @@ func RunSetup() (*config.Config, error) {
 		AIProvider: finalModel.provider,
 	}
 
-	if cfg.AIProvider == "openai" {
+	switch cfg.AIProvider {
+	case "openai":
 		cfg.AIKey = finalModel.answers["OpenAI API Key"]
-	} else {
+		cfg.BaseURL = finalModel.answers["Base URL"]
+		cfg.Model = finalModel.answers["Model"]
+	case "anthropic":
+		cfg.AnthropicAPIKey = finalModel.answers["Anthropic API Key"]
+		cfg.AnthropicModel = finalModel.answers["Model"]
+	case "ollama":
+		cfg.OllamaHost = finalModel.answers["Ollama Host"]
+		cfg.OllamaModel = finalModel.answers["Ollama Model"]
+	case "gemini":
+		cfg.GeminiAPIKey = finalModel.answers["Gemini API Key"]
+		cfg.GeminiModel = finalModel.answers["Model"]
+	default:
 		cfg.AzureEndpoint = finalModel.answers["Azure Endpoint"]
 		cfg.AzureDeploymentID = finalModel.answers["Azure Deployment ID"]
 		cfg.AzureAPIKey = finalModel.answers["Azure API Key"]
 		cfg.AzureAPIVersion = finalModel.answers["Azure API Version"]
 	}
 
+	// Empty answers keep the defaults; the values were validated on entry.
+	cfg.Temperature = 0.5
+	if t, err := strconv.ParseFloat(strings.TrimSpace(finalModel.answers["Temperature"]), 32); err == nil {
+		cfg.Temperature = float32(t)
+	}
+	cfg.MaxTokens, _ = strconv.Atoi(strings.TrimSpace(finalModel.answers["Max Tokens"]))
+	cfg.Candidates = 3
+	if n, err := strconv.Atoi(strings.TrimSpace(finalModel.answers["Candidates"])); err == nil {
+		cfg.Candidates = n
+	}
+
 	return cfg, nil
 }
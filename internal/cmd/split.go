@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/doganarif/giq/internal/app"
+	"github.com/spf13/cobra"
+)
+
+// splitModel is the review screen for the proposed commits.
+type splitModel struct {
+	groups    []app.SplitGroup
+	cursor    int
+	editing   bool
+	input     textinput.Model
+	confirmed bool
+}
+
+func initialSplitModel(groups []app.SplitGroup) splitModel {
+	ti := textinput.New()
+	ti.Prompt = "> "
+	ti.CharLimit = 200
+	ti.Width = 72
+	return splitModel{groups: groups, input: ti}
+}
+
+func (m splitModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m splitModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if m.editing {
+		return m.updateEditing(msg)
+	}
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch keyMsg.String() {
+	case "ctrl+c", "q", "esc":
+		return m, tea.Quit
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < len(m.groups)-1 {
+			m.cursor++
+		}
+	case "e":
+		m.editing = true
+		m.input.SetValue(m.groups[m.cursor].Message)
+		m.input.CursorEnd()
+		return m, m.input.Focus()
+	case "m":
+		// Merge the highlighted commit into the one above it.
+		if m.cursor > 0 {
+			above := &m.groups[m.cursor-1]
+			above.Hunks = append(above.Hunks, m.groups[m.cursor].Hunks...)
+			m.groups = append(m.groups[:m.cursor], m.groups[m.cursor+1:]...)
+			m.cursor--
+		}
+	case "enter", "y":
+		m.confirmed = true
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// updateEditing handles keys while a commit message is edited.
+func (m splitModel) updateEditing(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch keyMsg.String() {
+		case "ctrl+c":
+			return m, tea.Quit
+		case "enter":
+			if value := strings.TrimSpace(m.input.Value()); value != "" {
+				m.groups[m.cursor].Message = value
+			}
+			m.editing = false
+			m.input.Blur()
+			return m, nil
+		case "esc":
+			m.editing = false
+			m.input.Blur()
+			return m, nil
+		}
+	}
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m splitModel) View() string {
+	s := fmt.Sprintf("Proposed commits (%d):\n\n", len(m.groups))
+	for i, g := range m.groups {
+		cursor := "  "
+		if m.cursor == i {
+			cursor = "> "
+		}
+		s += fmt.Sprintf("%s%d. %s\n", cursor, i+1, g.Message)
+		if m.editing && m.cursor == i {
+			s += "     " + m.input.View() + "\n"
+		}
+		for _, h := range g.Hunks {
+			s += fmt.Sprintf("       %s\n", h)
+		}
+	}
+	if m.editing {
+		return s + "\nenter to save the message, esc to cancel"
+	}
+	return s + "\nUse ↑/↓ arrows to navigate, e to edit a message, m to merge into the commit above\nenter to create the commits, q to abort"
+}
+
+// NewSplitCommand creates the split command, which turns the staged changes
+// into several commits.
+func NewSplitCommand(a *app.App) *cobra.Command {
+	var showPrompt bool
+	var noVerifySecrets bool
+	cmd := &cobra.Command{
+		Use:   "split",
+		Short: "Split the staged changes into several logical commits",
+		Long: "Ask the AI to group the staged hunks into coherent commits, review the proposal, and create one commit per group.\n\n" +
+			"Every commit is prepared before any is created: if a patch does not apply or you abort, the branch " +
+			"and the index are left as they were. The working tree is never touched. Like git commit-tree, " +
+			"split does not run commit hooks.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			hunks, err := a.StagedHunks()
+			if err != nil {
+				return err
+			}
+			if len(hunks) == 0 {
+				return fmt.Errorf("no staged changes detected")
+			}
+			if len(hunks) == 1 {
+				return fmt.Errorf("only one hunk is staged, there is nothing to split; use giq commit")
+			}
+
+			if showPrompt {
+				prompt, err := a.SplitPrompt(hunks)
+				if err != nil {
+					return err
+				}
+				fmt.Println(prompt)
+				return nil
+			}
+
+			// Refuse to go any further with leaked credentials in the diff.
+			if !noVerifySecrets {
+				diff, _, err := a.CommitDiff(app.DiffOptions{})
+				if err != nil {
+					return err
+				}
+				if err := checkSecrets(a, diff); err != nil {
+					return err
+				}
+			}
+
+			fmt.Printf("Grouping %d staged hunks...\n", len(hunks))
+			groups, err := a.ProposeSplit(hunks)
+			if err != nil {
+				return err
+			}
+
+			m, err := tea.NewProgram(initialSplitModel(groups)).Run()
+			if err != nil {
+				return err
+			}
+			sm, ok := m.(splitModel)
+			if !ok {
+				return fmt.Errorf("unexpected model type")
+			}
+			if !sm.confirmed {
+				return fmt.Errorf("split aborted, nothing was committed")
+			}
+
+			for i, g := range sm.groups {
+				if sm.groups[i].Message, err = a.AddTickets(g.Message); err != nil {
+					return err
+				}
+			}
+			hashes, err := a.CommitSplit(sm.groups)
+			if err != nil {
+				return err
+			}
+			for i, h := range hashes {
+				fmt.Printf("%s %s\n", h[:7], sm.groups[i].Message)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&showPrompt, "show-prompt", false, "Print the prompt that would be sent to the AI and exit")
+	cmd.Flags().BoolVar(&noVerifySecrets, "no-verify-secrets", false, "Commit even if the staged changes appear to contain secrets")
+	return cmd
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/doganarif/giq/internal/ai"
@@ -12,7 +13,8 @@ import (
 // NewStatusCommand creates the status command which shows the working tree status
 // and AI-generated insights regarding the changes.
 func NewStatusCommand(a *app.App) *cobra.Command {
-	return &cobra.Command{
+	var showPrompt bool
+	cmd := &cobra.Command{
 		Use:   "status",
 		Short: "Show working tree status with AI insights",
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -44,8 +46,20 @@ func NewStatusCommand(a *app.App) *cobra.Command {
 				return nil
 			}
 
+			// Apply privacy rules and condense large diffs before anything leaves the machine.
+			prepared, err := a.PrepareDiff(diff)
+			if err != nil {
+				return err
+			}
+			if showPrompt {
+				fmt.Println()
+				fmt.Println(ai.StatusInsightsPrompt(prepared.Text))
+				return nil
+			}
+			printDiffNotes(os.Stdout, prepared)
+
 			// Generate AI insights based on the diff output.
-			insights, err := ai.GenerateStatusInsights(a.Config, diff)
+			insights, err := ai.GenerateStatusInsights(a.Config, prepared.Text)
 			if err != nil {
 				fmt.Println("\n[Warning: Could not generate AI insights]")
 			} else {
@@ -55,4 +69,7 @@ func NewStatusCommand(a *app.App) *cobra.Command {
 			return nil
 		},
 	}
+
+	cmd.Flags().BoolVar(&showPrompt, "show-prompt", false, "Print the prompt that would be sent to the AI and exit")
+	return cmd
 }
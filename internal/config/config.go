@@ -1,8 +1,13 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -15,17 +20,99 @@ type Config struct {
 	AzureDeploymentID string `mapstructure:"azure_deployment_id"`
 	AzureAPIKey       string `mapstructure:"azure_api_key"`
 	AzureAPIVersion   string `mapstructure:"azure_api_version"`
+	AnthropicAPIKey   string `mapstructure:"anthropic_api_key"`
+	AnthropicModel    string `mapstructure:"anthropic_model"`
+	AnthropicBaseURL  string `mapstructure:"anthropic_base_url"`
+	OllamaHost        string `mapstructure:"ollama_host"`
+	OllamaModel       string `mapstructure:"ollama_model"`
+	OllamaKeepAlive   string `mapstructure:"ollama_keep_alive"`
+	GeminiAPIKey      string `mapstructure:"gemini_api_key"`
+	GeminiModel       string `mapstructure:"gemini_model"`
+
+	// Generic request settings shared by all providers. BaseURL and Model
+	// apply to the OpenAI-compatible providers (openai, azure_openai).
+	BaseURL     string  `mapstructure:"base_url"`
+	Model       string  `mapstructure:"model"`
+	Temperature float32 `mapstructure:"temperature"`
+	MaxTokens   int     `mapstructure:"max_tokens"`
+	Candidates  int     `mapstructure:"candidates"`
+
+	// AIProviders is an ordered fallback chain. When set it takes
+	// precedence over AIProvider.
+	AIProviders      []string                 `mapstructure:"ai_providers"`
+	Timeout          time.Duration            `mapstructure:"timeout"`
+	ProviderTimeouts map[string]time.Duration `mapstructure:"provider_timeouts"`
+	Verbose          bool                     `mapstructure:"verbose"`
+
+	// MaxRetries is the number of retries for rate-limited (429) and
+	// transient (5xx, network) failures. RetryDelay is the initial backoff.
+	MaxRetries int           `mapstructure:"max_retries"`
+	RetryDelay time.Duration `mapstructure:"retry_delay"`
+
+	// DiffBudget is the approximate number of tokens of diff sent to the
+	// model. DiffBudgets overrides it per model name.
+	DiffBudget  int            `mapstructure:"diff_budget"`
+	DiffBudgets map[string]int `mapstructure:"diff_budgets"`
+
+	// ExcludePaths are glob patterns of files whose content is never sent
+	// to the AI. RedactPatterns are regular expressions whose matches are
+	// masked in the remaining diff. Both are combined with the values from
+	// the repository's .giq.yaml.
+	ExcludePaths   []string `mapstructure:"exclude_paths"`
+	RedactPatterns []string `mapstructure:"redact_patterns"`
+
+	// CommitStyle selects the format of generated commit messages. The only
+	// style besides the default free-form one is "conventional".
+	CommitStyle string `mapstructure:"commit_style"`
+	// CommitBody asks for a subject line followed by a body explaining why
+	// the change was made, instead of a single line.
+	CommitBody bool `mapstructure:"commit_body"`
+	// StyleSamples is the number of recent commit subjects shown to the AI
+	// as examples of the repository's style. Zero disables it.
+	StyleSamples int `mapstructure:"style_samples"`
+
+	// TicketPattern is a regular expression matching ticket IDs in branch
+	// names, such as `[A-Z]+-\d+`. TicketTemplate says where they go in the
+	// commit message: a subject template containing {subject}, or a trailer.
+	TicketPattern  string `mapstructure:"ticket_pattern"`
+	TicketTemplate string `mapstructure:"ticket_template"`
 }
 
+// RepoConfigFile is the name of the per-repository configuration file,
+// looked up in the root of the working tree.
+const RepoConfigFile = ".giq.yaml"
+
+// repoKeys are the settings a repository config may set. Providers,
+// endpoints, credentials and request settings are left out: a cloned
+// repository must not be able to send the user's API key or diff elsewhere.
+var repoKeys = map[string]bool{
+	"exclude_paths":   true,
+	"redact_patterns": true,
+	"commit_style":    true,
+	"commit_body":     true,
+	"style_samples":   true,
+	"ticket_pattern":  true,
+	"ticket_template": true,
+	"diff_budget":     true,
+	"diff_budgets":    true,
+}
+
+// additiveKeys are list settings that a repository config extends instead of
+// replacing, so a repository can never weaken the global privacy settings.
+var additiveKeys = []string{"exclude_paths", "redact_patterns"}
+
 // Load reads configuration from common config file locations and environment variables.
 // If no config file is found, it creates one in $HOME/.config/giq/config.yaml with extended commented instructions.
-func Load() (*Config, error) {
+// If repoRoot is not empty, settings from the repository's .giq.yaml are merged on top.
+func Load(repoRoot string) (*Config, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return nil, err
 	}
 
-	v := viper.New()
+	// Model names such as "llama3.2" are used as map keys, so the default
+	// "." key delimiter would split them into nested keys.
+	v := viper.NewWithOptions(viper.KeyDelimiter("::"))
 	v.SetConfigName("config")
 	v.SetConfigType("yaml")
 	// Search in current directory, then in $HOME/.config/giq and $HOME/.giq.
@@ -36,9 +123,18 @@ func Load() (*Config, error) {
 	// Environment variables prefixed with GIQ_ are also read.
 	v.SetEnvPrefix("GIQ")
 	v.AutomaticEnv()
+	// AutomaticEnv only applies to keys viper already knows about, so bind
+	// every key explicitly to make env-only configuration work.
+	bindEnv(v, reflect.TypeOf(Config{}))
 
 	// Set default values.
 	v.SetDefault("ai_provider", "openai")
+	v.SetDefault("temperature", 0.5)
+	v.SetDefault("candidates", 3)
+	v.SetDefault("max_retries", 2)
+	v.SetDefault("retry_delay", "1s")
+	v.SetDefault("style_samples", 10)
+	v.SetDefault("ticket_template", "Refs: {ticket}")
 
 	// Attempt to read the config file.
 	err = v.ReadInConfig()
@@ -59,9 +155,76 @@ func Load() (*Config, error) {
 # ai_provider: Specify the AI provider to use. Options include:
 #    - openai (default)
 #    - azure_openai
+#    - anthropic
+#    - ollama (local, no API key required)
+#    - gemini
+#
+# ai_providers: (optional) An ordered list of providers to try in turn. If a
+#               provider errors, times out or returns nothing, the next one
+#               is used. Takes precedence over ai_provider.
+# timeout: (optional) Deadline for a single provider call (default: 60s).
+# provider_timeouts: (optional) Per-provider deadlines overriding timeout.
+# verbose: (optional) Report which provider answered (same as --verbose).
+# max_retries: (optional) Retries for rate-limited (429) and transient (5xx)
+#              failures (default: 2, 0 disables retries).
+# retry_delay: (optional) Initial backoff between retries, doubled on every
+#              attempt with jitter (default: 1s). Retry-After headers sent
+#              by the provider take precedence.
+#
+# diff_budget: (optional) Approximate number of tokens of staged diff sent to
+#              the model (default: 8000). Lockfiles, minified, vendored and
+#              binary files are summarized, whitespace-only changes are
+#              collapsed, and the largest files are reduced to line counts
+#              until the diff fits.
+# exclude_paths: (optional) Glob patterns of files whose content is never sent
+#                to the AI, e.g. ["secrets/**", "*.pem", ".env*"]. Patterns
+#                without a slash match the file name in any directory.
+# redact_patterns: (optional) Regular expressions whose matches are replaced
+#                  by [REDACTED] before the diff is sent. If a pattern has a
+#                  capture group, only the group is redacted. Common token
+#                  formats are redacted even without configuration.
+#
+# commit_style: (optional) Set to "conventional" to generate Conventional
+#               Commits messages such as "feat(ai): add streaming". The scope
+#               is inferred from the staged paths (internal/ai -> ai), and
+#               suggestions that do not follow the format are repaired or
+#               regenerated.
+# commit_body: (optional) Generate a subject line followed by a body, wrapped
+#              at 72 columns, that explains why the change was made
+#              (default: false, same as giq commit --body).
+# style_samples: (optional) Number of recent commit subjects sampled as
+#                examples so suggestions match the casing, prefixes, ticket
+#                references, language and length the repository already
+#                uses (default: 10, 0 disables). The derived style is cached
+#                in the git directory for a day.
+# ticket_pattern: (optional) Regular expression matching ticket IDs in the
+#                 branch name, e.g. '[A-Z]+-\d+' for Jira keys or '#\d+'. If it
+#                 has a capture group, the group is the ID. Every commit made
+#                 with giq, including -m messages, gets the IDs it is missing.
+# ticket_template: (optional) Where the IDs go: a template containing
+#                  {subject}, e.g. "[{ticket}] {subject}", rewrites the subject
+#                  line, anything else is added as a trailer
+#                  (default: "Refs: {ticket}").
+#
+# Per-repository settings can be placed in a .giq.yaml file at the root of a
+# repository. Its exclude_paths and redact_patterns extend the global ones;
+# commit_style, commit_body, style_samples, ticket_pattern, ticket_template,
+# diff_budget and diff_budgets override them. Providers, endpoints, API keys
+# and request settings are only read from this file and the environment, so
+# a cloned repository cannot send your key or diffs elsewhere.
+#
+# diff_budgets: (optional) Per-model overrides of diff_budget, e.g.
+#               diff_budgets:
+#                 gpt-4o-mini: 60000
+#                 llama3.2: 4000
 #
 # For OpenAI, configure the following:
 #   ai_key: Your API key for OpenAI.
+#   base_url: (optional) Base URL of an OpenAI-compatible API, e.g. vLLM,
+#             LiteLLM, OpenRouter or a corporate gateway
+#             (e.g., http://localhost:8000/v1). ai_key may be left empty
+#             when the gateway does not require one.
+#   model: (optional) The model to request (default: gpt-4o-mini).
 #
 # For Azure OpenAI, configure the following:
 #   azure_endpoint: The endpoint for your Azure OpenAI resource
@@ -70,11 +233,48 @@ func Load() (*Config, error) {
 #   azure_api_key: Your API key for Azure OpenAI.
 #   azure_api_version: The API version for Azure OpenAI (e.g., 2022-12-01).
 #
+# For Anthropic, configure the following:
+#   anthropic_api_key: Your API key for Anthropic.
+#   anthropic_model: (optional) The Claude model to use (default: claude-haiku-4-5).
+#   anthropic_base_url: (optional) Override the API base URL
+#                       (default: https://api.anthropic.com).
+#
+# For Ollama, configure the following (all optional):
+#   ollama_host: The Ollama server address (default: http://localhost:11434).
+#   ollama_model: The local model to use (default: llama3.2).
+#   ollama_keep_alive: How long the model stays loaded after a request
+#                      (e.g., 5m, 1h, -1 to keep it loaded).
+#
+# For Google Gemini, configure the following:
+#   gemini_api_key: Your API key for the Gemini API.
+#   gemini_model: (optional) The Gemini model to use (default: gemini-2.0-flash).
+#
+# Request settings (optional, apply to every provider):
+#   temperature: Sampling temperature (default: 0.5).
+#   max_tokens: Maximum tokens per completion (default: 64 for commit
+#               messages, 320 with commit_body, 128 for status insights).
+#               Split plans, PR descriptions and changelogs size their own.
+#   candidates: Number of commit message suggestions to generate (default: 3).
+#
 # Example configuration for OpenAI:
 #
 #   ai_provider: openai
 #   ai_key: your-openai-api-key
 #
+# Example configuration for an OpenAI-compatible gateway:
+#
+#   ai_provider: openai
+#   base_url: https://openrouter.ai/api/v1
+#   ai_key: your-gateway-key
+#   model: anthropic/claude-3.5-haiku
+#
+# Example fallback chain (local first, then the cloud):
+#
+#   ai_providers: [ollama, azure_openai, openai]
+#   timeout: 30s
+#   provider_timeouts:
+#     ollama: 10s
+#
 # Example configuration for Azure OpenAI:
 #
 #   ai_provider: azure_openai
@@ -83,6 +283,21 @@ func Load() (*Config, error) {
 #   azure_api_key: your-azure-api-key
 #   azure_api_version: 2022-12-01
 #
+# Example configuration for Anthropic:
+#
+#   ai_provider: anthropic
+#   anthropic_api_key: your-anthropic-api-key
+#
+# Example configuration for Ollama:
+#
+#   ai_provider: ollama
+#   ollama_model: llama3.2
+#
+# Example configuration for Gemini:
+#
+#   ai_provider: gemini
+#   gemini_api_key: your-gemini-api-key
+#
 `
 			_ = os.WriteFile(configFile, []byte(defaultContent), 0644)
 		} else {
@@ -90,6 +305,12 @@ func Load() (*Config, error) {
 		}
 	}
 
+	if repoRoot != "" {
+		if err := mergeRepoConfig(v, filepath.Join(repoRoot, RepoConfigFile)); err != nil {
+			return nil, err
+		}
+	}
+
 	var cfg Config
 	if err := v.Unmarshal(&cfg); err != nil {
 		return nil, err
@@ -97,3 +318,56 @@ func Load() (*Config, error) {
 
 	return &cfg, nil
 }
+
+// bindEnv binds a GIQ_ environment variable for every mapstructure key of t.
+func bindEnv(v *viper.Viper, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		if key := t.Field(i).Tag.Get("mapstructure"); key != "" {
+			_ = v.BindEnv(key)
+		}
+	}
+}
+
+// mergeRepoConfig merges the repository config file at path, if it exists,
+// over the settings already loaded into v. Only repoKeys are merged; other
+// settings are ignored with a warning.
+func mergeRepoConfig(v *viper.Viper, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	repo := viper.NewWithOptions(viper.KeyDelimiter("::"))
+	repo.SetConfigType("yaml")
+	if err := repo.ReadConfig(f); err != nil {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	global := make(map[string][]string, len(additiveKeys))
+	for _, key := range additiveKeys {
+		global[key] = v.GetStringSlice(key)
+	}
+	settings := repo.AllSettings()
+	var ignored []string
+	for key := range settings {
+		if !repoKeys[key] {
+			ignored = append(ignored, key)
+			delete(settings, key)
+		}
+	}
+	if len(ignored) > 0 {
+		sort.Strings(ignored)
+		fmt.Fprintf(os.Stderr, "giq: ignoring %s in %s, set them in your global config instead\n", strings.Join(ignored, ", "), path)
+	}
+	if err := v.MergeConfigMap(settings); err != nil {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+	for _, key := range additiveKeys {
+		v.Set(key, append(global[key], repo.GetStringSlice(key)...))
+	}
+	return nil
+}
@@ -18,12 +18,19 @@ func main() {
 
 	// Define the commands handled by giq.
 	handledCommands := map[string]bool{
-		"commit": true,
-		"status": true,
-		"help":   true,
-		"setup":  true,
-		"--help": true,
-		"-h":     true,
+		"commit":    true,
+		"status":    true,
+		"help":      true,
+		"setup":     true,
+		"hook":      true,
+		"reword":    true,
+		"split":     true,
+		"add":       true,
+		"pr":        true,
+		"changelog": true,
+		"release":   true,
+		"--help":    true,
+		"-h":        true,
 	}
 
 	// If there are arguments and the first argument is not one of our custom commands,